@@ -0,0 +1,120 @@
+package useragent
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LogReport counts the user agents of an access log by the ids the
+// generator uses, see AnalyzeLog.
+type LogReport struct {
+	// Lines is the number of log lines, Malformed the number of them
+	// not in combined log format
+	Lines     int `json:"lines"`
+	Malformed int `json:"malformed"`
+	// Recognized is the number of requests with a user agent Parse
+	// understands, counted in the maps below
+	Recognized   int            `json:"recognized"`
+	ByNavigator  map[string]int `json:"by_navigator"`
+	ByOS         map[string]int `json:"by_os"`
+	ByPlatform   map[string]int `json:"by_platform"`
+	ByDeviceType map[string]int `json:"by_device_type"`
+	// ByMajor counts browser majors, e.g. "chrome 86" or "ie 11"
+	ByMajor map[string]int `json:"by_major"`
+	// ByVariant counts device type, os and navigator combinations,
+	// e.g. "desktop/win/chrome"
+	ByVariant map[string]int `json:"by_variant"`
+	// Unknown counts requests by user agent Parse does not understand,
+	// "-" is a request without user agent
+	Unknown map[string]int `json:"unknown"`
+}
+
+// Combined log format: host ident user [time] "request" status bytes "referer" "user agent"
+var reCombinedLog = regexp.MustCompile(`^\S+ \S+ \S+ \[[^\]]*\] "(?:[^"\\]|\\.)*" \d{3} \S+ "(?:[^"\\]|\\.)*" "((?:[^"\\]|\\.)*)"`)
+
+// AnalyzeLog classifies the user agents of an nginx or Apache access log
+// in combined log format with Parse. Lines in other formats are counted as
+// malformed and skipped. The error is the one of reading r, if any.
+func AnalyzeLog(r io.Reader) (*LogReport, error) {
+	return analyzeLines(r, true)
+}
+
+// AnalyzeUAList is AnalyzeLog for a list of user agents, one per line.
+func AnalyzeUAList(r io.Reader) (*LogReport, error) {
+	return analyzeLines(r, false)
+}
+
+func analyzeLines(r io.Reader, combined bool) (*LogReport, error) {
+	report := &LogReport{
+		ByNavigator:  make(map[string]int),
+		ByOS:         make(map[string]int),
+		ByPlatform:   make(map[string]int),
+		ByDeviceType: make(map[string]int),
+		ByMajor:      make(map[string]int),
+		Unknown:      make(map[string]int),
+		ByVariant:    make(map[string]int),
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		report.Lines++
+		if !combined {
+			report.add(strings.TrimSpace(line))
+			continue
+		}
+		m := reCombinedLog.FindStringSubmatch(line)
+		if m == nil {
+			report.Malformed++
+			continue
+		}
+		report.add(unescapeLogField(m[1]))
+	}
+	return report, scanner.Err()
+}
+
+func (report *LogReport) add(ua string) {
+	p, err := Parse(ua)
+	if err != nil {
+		report.Unknown[ua]++
+		return
+	}
+	report.Recognized++
+	report.ByNavigator[p.Navigator]++
+	report.ByOS[p.OS]++
+	report.ByPlatform[p.PlatformVersion]++
+	report.ByDeviceType[p.DeviceType]++
+	report.ByMajor[p.Navigator+" "+strconv.Itoa(majorVersion(p.Version))]++
+	report.ByVariant[p.DeviceType+"/"+p.OS+"/"+p.Navigator]++
+}
+
+// Undo the escaping of quoted log fields: \" and \\ by Apache,
+// \xHH by nginx
+func unescapeLogField(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		if s[i+1] == 'x' && i+3 < len(s) {
+			if v, err := strconv.ParseUint(s[i+2:i+4], 16, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 3
+				continue
+			}
+		}
+		i++
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
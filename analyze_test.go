@@ -0,0 +1,48 @@
+package useragent
+
+import (
+	"strings"
+	"testing"
+)
+
+const testAccessLog = `127.0.0.1 - - [10/Oct/2020:13:55:36 -0700] "GET / HTTP/1.1" 200 2326 "-" "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/86.0.4240.75 Safari/537.36"
+127.0.0.1 - - [10/Oct/2020:13:55:37 -0700] "GET /a HTTP/1.1" 200 12 "http://example.com/" "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/86.0.4240.111 Safari/537.36"
+10.0.0.2 - frank [10/Oct/2020:13:56:00 +0000] "GET /b HTTP/1.1" 304 - "-" "Mozilla/5.0 (Android 7.0; Mobile; rv:50.0) Gecko/50.0 Firefox/50.0"
+10.0.0.3 - - [10/Oct/2020:13:57:00 +0000] "GET /c HTTP/1.1" 200 5 "-" "Mozilla/5.0 (Windows NT 6.1; Trident/7.0; rv:11.0) like Gecko"
+10.0.0.4 - - [10/Oct/2020:13:58:00 +0000] "GET /d HTTP/1.1" 200 5 "-" "curl/7.68.0"
+10.0.0.5 - - [10/Oct/2020:13:58:01 +0000] "GET /\"q\" HTTP/1.1" 404 5 "-" "-"
+10.0.0.6 - - [10/Oct/2020:13:58:02 +0000] "GET /e HTTP/1.1" 200 5 "-" "Bot \x22quoted\x22"
+not a log line
+
+`
+
+func TestAnalyzeLog(t *testing.T) {
+	report, err := AnalyzeLog(strings.NewReader(testAccessLog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Lines != 8 || report.Malformed != 1 || report.Recognized != 4 {
+		t.Errorf("lines %d, malformed %d, recognized %d", report.Lines, report.Malformed, report.Recognized)
+	}
+	for _, tt := range []struct {
+		name      string
+		got, want int
+	}{
+		{"chrome", report.ByNavigator["chrome"], 2},
+		{"ie", report.ByNavigator["ie"], 1},
+		{"win", report.ByOS["win"], 3},
+		{"android", report.ByOS["android"], 1},
+		{"Windows NT 10.0", report.ByPlatform["Windows NT 10.0"], 2},
+		{"desktop", report.ByDeviceType["desktop"], 3},
+		{"smartphone", report.ByDeviceType["smartphone"], 1},
+		{"chrome 86", report.ByMajor["chrome 86"], 2},
+		{"ie 11", report.ByMajor["ie 11"], 1},
+		{"curl/7.68.0", report.Unknown["curl/7.68.0"], 1},
+		{"-", report.Unknown["-"], 1},
+		{`Bot "quoted"`, report.Unknown[`Bot "quoted"`], 1},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("%s: %d, want %d", tt.name, tt.got, tt.want)
+		}
+	}
+}
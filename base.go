@@ -29,19 +29,22 @@ FIXME:
 
 package useragent
 
+//go:generate go run ./internal/gendata
+
 import (
-	"bytes"
 	"crypto/rand"
+	"encoding/binary"
 	"errors"
 	"fmt"
-	"math/big"
-	"strconv"
 	"strings"
-	"text/template"
 	"time"
 )
 
 var (
+	// The tables below, and the ones of releases_gen.go, are read by
+	// NewRegistry to build the built-in operating systems and browsers.
+	// DEVICE_TYPE_OS, DEVICE_TYPE_NAVIGATOR and OS_NAVIGATOR are the
+	// inverse of OS_DEVICE_TYPE, NAVIGATOR_DEVICE_TYPE and NAVIGATOR_OS.
 	DEVICE_TYPE_OS = map[string][]string{
 		"desktop":    {"win", "mac", "linux"},
 		"smartphone": {"android"},
@@ -89,38 +92,7 @@ var (
 			"X11; Linux",
 			"X11; Ubuntu; Linux",
 		},
-		"android": {
-			// 2013-10-31
-			"Android 4.4",
-			// 2013-12-05
-			"Android 4.4.1",
-			// 2013-12-09
-			"Android 4.4.2",
-			// 2014-06-02
-			"Android 4.4.3",
-			// 2014-06-19
-			"Android 4.4.4",
-			// 2014-11-12
-			"Android 5.0",
-			// 2014-12-02
-			"Android 5.0.1",
-			// 2014-12-19
-			"Android 5.0.2",
-			// 2015-03-09
-			"Android 5.1",
-			// 2015-04-21
-			"Android 5.1.1",
-			// 2015-10-05
-			"Android 6.0",
-			// 2015-12-07
-			"Android 6.0.1",
-			// 2016-08-22
-			"Android 7.0",
-			// 2016-10-04
-			"Android 7.1",
-			// 2016-12-05
-			"Android 7.1.1",
-		},
+		"android": ANDROID_PLATFORM,
 	}
 
 	OS_CPU = map[string][]string{
@@ -159,79 +131,79 @@ var (
 		"firefox": {"win", "linux", "mac", "android"},
 		"ie":      {"win"},
 	}
-	MACOSX_CHROME_BUILD_RANGE = map[string][]int{
-		// https://en.wikipedia.org/wiki/MacOS#Release_history
-		"10.8":  {0, 8},
-		"10.9":  {0, 5},
-		"10.10": {0, 5},
-		"10.11": {0, 6},
-		"10.12": {0, 2},
-	}
-
-	FIREFOX_VERSION = []FirefoxVersion{
-		{"45.0", time.Date(2016, 3, 8, 0, 0, 0, 0, time.UTC)},
-		{"46.0", time.Date(2016, 4, 26, 0, 0, 0, 0, time.UTC)},
-		{"47.0", time.Date(2016, 6, 7, 0, 0, 0, 0, time.UTC)},
-		{"48.0", time.Date(2016, 8, 2, 0, 0, 0, 0, time.UTC)},
-		{"49.0", time.Date(2016, 9, 20, 0, 0, 0, 0, time.UTC)},
-		{"50.0", time.Date(2016, 11, 15, 0, 0, 0, 0, time.UTC)},
-		{"51.0", time.Date(2017, 1, 24, 0, 0, 0, 0, time.UTC)},
-	}
-
-	// Top chrome builds from website access log
-	// for september, october 2020
-	CHROME_BUILD = []string{
-		"80.0.3987.132",
-		"80.0.3987.149",
-		"80.0.3987.99",
-		"81.0.4044.117",
-		"81.0.4044.138",
-		"83.0.4103.101",
-		"83.0.4103.106",
-		"83.0.4103.96",
-		"84.0.4147.105",
-		"84.0.4147.111",
-		"84.0.4147.125",
-		"84.0.4147.135",
-		"84.0.4147.89",
-		"85.0.4183.101",
-		"85.0.4183.102",
-		"85.0.4183.120",
-		"85.0.4183.121",
-		"85.0.4183.127",
-		"85.0.4183.81",
-		"85.0.4183.83",
-		"86.0.4240.110",
-		"86.0.4240.111",
-		"86.0.4240.114",
-		"86.0.4240.183",
-		"86.0.4240.185",
-		"86.0.4240.75",
-		"86.0.4240.78",
-		"86.0.4240.80",
-		"86.0.4240.96",
-		"86.0.4240.99",
-	}
-
-	// (numeric ver, string ver, trident ver)
+
+	// (numeric ver, string ver, trident ver, release date)
 	IE_VERSION = []IEVersion{
-		//2009
-		{8, "MSIE 8.0", "4.0"},
-		//2011
-		{9, "MSIE 9.0", "5.0"},
-		//2012
-		{10, "MSIE 10.0", "6.0"},
-		//2013
-		{11, "MSIE 11.0", "7.0"},
+		{8, "MSIE 8.0", "4.0", time.Date(2009, 3, 19, 0, 0, 0, 0, time.UTC)},
+		{9, "MSIE 9.0", "5.0", time.Date(2011, 3, 14, 0, 0, 0, 0, time.UTC)},
+		{10, "MSIE 10.0", "6.0", time.Date(2012, 10, 26, 0, 0, 0, 0, time.UTC)},
+		{11, "MSIE 11.0", "7.0", time.Date(2013, 10, 17, 0, 0, 0, 0, time.UTC)},
+	}
+
+	// Range of browser majors each platform can run. Platforms that are not
+	// listed have no known limit. A platform also matches its point
+	// releases, i.e. "Android 4.4" matches "Android 4.4.2".
+	SUPPORT_MATRIX = map[string]map[string]SupportRange{
+		"chrome": {
+			// Chrome 50 dropped XP, Vista and OS X 10.6-10.8
+			"Windows NT 5.1":                 {Max: 49},
+			"Macintosh; Intel Mac OS X 10.8": {Max: 49},
+			// Chrome 68 dropped OS X 10.9
+			"Macintosh; Intel Mac OS X 10.9": {Max: 67},
+			// Chrome 88 dropped OS X 10.10
+			"Macintosh; Intel Mac OS X 10.10": {Max: 87},
+			// Chrome 104 dropped macOS 10.11 and 10.12
+			"Macintosh; Intel Mac OS X 10.11": {Max: 103},
+			"Macintosh; Intel Mac OS X 10.12": {Max: 103},
+			// Chrome 110 dropped Windows 7, 8 and 8.1
+			"Windows NT 6.1": {Max: 109},
+			"Windows NT 6.2": {Max: 109},
+			"Windows NT 6.3": {Max: 109},
+			// Chrome 96 dropped Android KitKat
+			"Android 4.4": {Max: 95},
+		},
+		"firefox": {
+			// Firefox 52 ESR is the last for XP and Vista
+			"Windows NT 5.1": {Max: 52},
+			// Firefox 115 ESR is the last for Windows 7, 8 and 8.1
+			"Windows NT 6.1": {Max: 115},
+			"Windows NT 6.2": {Max: 115},
+			"Windows NT 6.3": {Max: 115},
+			// Firefox 48 is the last for OS X 10.6-10.8, then 45 ESR
+			"Macintosh; Intel Mac OS X 10.8": {Max: 48},
+			// Firefox 78 ESR is the last for OS X 10.9-10.11
+			"Macintosh; Intel Mac OS X 10.9":  {Max: 78},
+			"Macintosh; Intel Mac OS X 10.10": {Max: 78},
+			"Macintosh; Intel Mac OS X 10.11": {Max: 78},
+			// Firefox 115 ESR is the last for macOS 10.12-10.14
+			"Macintosh; Intel Mac OS X 10.12": {Max: 115},
+			// Firefox 68 is the last for Android KitKat
+			"Android 4.4": {Max: 68},
+		},
+		"ie": {
+			"Windows NT 5.1": {Max: 8},
+			// Windows 7 ships IE 8
+			"Windows NT 6.1": {Min: 8, Max: 11},
+			// Windows 8 ships IE 10 and can't upgrade
+			"Windows NT 6.2":  {Min: 10, Max: 10},
+			"Windows NT 6.3":  {Min: 11, Max: 11},
+			"Windows NT 10.0": {Min: 11, Max: 11},
+		},
 	}
 
+	// USERAGENTTEMPLATE are the User-Agent templates of the built-in
+	// browsers by name, text/templates executed with the System and App
+	// components as fields, e.g. {{.System.UAPlatform}}, see
+	// Generator.RegisterTemplate. Templates of earlier versions, where the
+	// components were maps, e.g. {{index .System "ua_platform"}}, still
+	// work: the keys are rewritten to the fields.
 	USERAGENTTEMPLATE = map[string]any{
-		"firefox":           `Mozilla/5.0 ({{index .System "ua_platform"}}; rv:{{index .App "build_version"}}) Gecko/{{index .App "geckotrail"}} Firefox/{{index .App "build_version"}}`,
-		"chrome":            `Mozilla/5.0 ({{index .System "ua_platform"}}) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/{{index .App "build_version"}} Safari/537.36`,
-		"chrome_smartphone": `Mozilla/5.0 ({{index .System "ua_platform"}}) AppleWebKit/537.36	(KHTML, like Gecko) Chrome/{{index .App "build_version"}} Mobile Safari/537.36`,
-		"chrome_tablet":     `Mozilla/5.0 ({{index .System "ua_platform"}}) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/{{index .App "build_version"}} Safari/537.36`,
-		"ie_less_11":        `Mozilla/5.0 (compatible; {{index .App "build_version"}}; {{index .System "ua_platform"}}; Trident/{{index .App "trident_version"}})`,
-		"ie_11":             `Mozilla/5.0 ({{index .System "ua_platform"}}; Trident/{{index .App "trident_version"}}; rv:11.0) like Gecko`,
+		"firefox":           `Mozilla/5.0 ({{.System.UAPlatform}}; rv:{{.App.BuildVersion}}) Gecko/{{.App.GeckoTrail}} Firefox/{{.App.BuildVersion}}`,
+		"chrome":            `Mozilla/5.0 ({{.System.UAPlatform}}) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/{{.App.BuildVersion}} Safari/537.36`,
+		"chrome_smartphone": `Mozilla/5.0 ({{.System.UAPlatform}}) AppleWebKit/537.36	(KHTML, like Gecko) Chrome/{{.App.BuildVersion}} Mobile Safari/537.36`,
+		"chrome_tablet":     `Mozilla/5.0 ({{.System.UAPlatform}}) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/{{.App.BuildVersion}} Safari/537.36`,
+		"ie_less_11":        `Mozilla/5.0 (compatible; {{.App.BuildVersion}}; {{.System.UAPlatform}}; Trident/{{.App.TridentVersion}})`,
+		"ie_11":             `Mozilla/5.0 ({{.System.UAPlatform}}; Trident/{{.App.TridentVersion}}; rv:11.0) like Gecko`,
 	}
 )
 
@@ -245,204 +217,158 @@ type (
 		NumericVersion int
 		StringVersion  string
 		TridentVersion string
+		Date           time.Time
+	}
+
+	// SupportRange is the range of browser major versions a platform
+	// can run. Zero Min or Max means no limit.
+	SupportRange struct {
+		Min int
+		Max int
 	}
 
 	DevIDs []string
 
 	UserAgentConfig struct {
 		//OS limit list of os for generation
-		//OS can be string or array
+		//OS can be string or array, OSID or []OSID
 		//oprtional:default
 		OS any
+		//OSes is OS typed, e.g. []OSID{OSWindows, OSMac},
+		//only one of them can be set
+		//Optional
+		OSes []OSID
 		//Navigator is the limit list of browser engines for generation
-		//Navigator can be a string or a list
+		//Navigator can be a string or a list, NavigatorID or []NavigatorID
 		//Default:Desktop
 		//Optional
 		Navigator any
+		//Navigators is Navigator typed, e.g. []NavigatorID{NavigatorChrome},
+		//only one of them can be set
+		//Optional
+		Navigators []NavigatorID
 		//DeviceType limits possible oses by device type
 		//DeviceType is a list, possible values:"desktop", "smartphone", "tablet", "all"
 		DeviceType []string
-		//Platform limits possible platforms by platform
+		//DeviceTypes is DeviceType typed, e.g. []DeviceType{DeviceTablet},
+		//only one of them can be set
+		//Optional
+		DeviceTypes []DeviceType
+		//Platform limits possible platforms by platform version,
+		//e.g. "Windows NT 10.0" or "Android 7.0"
 		//Default:""
 		//Optional
 		Platform []string
+		//AsOf limits builds to the ones released by the date,
+		//builds with unknown release date are left out
+		//Default: zero, no limit
+		//Optional
+		AsOf time.Time
+		//Versions limits builds by navigator id,
+		//e.g. {"chrome": {Min: "84"}, "firefox": {Min: "47", Max: "50"}}
+		//Optional
+		Versions map[string]VersionRange
+		//PlatformVersions limits platform versions by os id,
+		//e.g. {"win": {Min: "Windows NT 10"}, "android": {Min: "7"}}
+		//Optional
+		PlatformVersions map[string]VersionRange
+		//Exclude leaves navigators, platforms, cpus, device ids and
+		//user agents out, see Exclude
+		//Optional
+		Exclude Exclude
 	}
 
 	uatmpl struct {
-		System map[string]string
-		App    map[string]string
+		System System
+		App    App
 	}
 )
 
-func getFirefoxBuild() (string, string, error) {
-	nBig, err := rand.Int(rand.Reader, big.NewInt(int64(len(FIREFOX_VERSION))))
-	if err != nil {
-		return "", "", err
-	}
-	fxvs := FIREFOX_VERSION[nBig.Int64()]
-	//var index int
-	//var dateto time.Time
-	build_ver, date_from := fxvs.Version, fxvs.Version
-	return build_ver, date_from, nil //build_rnd_time.strftime("%Y%m%d%H%M%S")
-}
-
-func getChromeBuild() (string, error) {
-	nBig, err := rand.Int(rand.Reader, big.NewInt(int64(len(CHROME_BUILD))))
-	if err != nil {
-		return "", err
+// Look up the range of the platform in a SUPPORT_MATRIX entry, falling
+// back to the platform's minor release, e.g. "Android 4.4.2" to "Android 4.4".
+func supportRange(platforms map[string]SupportRange, platformVersion string) (SupportRange, bool) {
+	for key := platformVersion; ; {
+		if r, ok := platforms[key]; ok {
+			return r, true
+		}
+		i := strings.LastIndex(key, ".")
+		if i < 0 {
+			return SupportRange{}, false
+		}
+		key = key[:i]
 	}
-	return CHROME_BUILD[nBig.Int64()], nil
 }
 
-func getIEBuild() (IEVersion, error) {
-	nBig, err := rand.Int(rand.Reader, big.NewInt(int64(len(IE_VERSION))))
-	if err != nil {
-		return IEVersion{}, err
-	}
-	return IE_VERSION[nBig.Int64()], nil
-
+func (r SupportRange) contains(major int) bool {
+	return (r.Min == 0 || major >= r.Min) && (r.Max == 0 || major <= r.Max)
 }
 
-//Fix chrome version on mac OS.
-//Chrome on Mac OS adds minor version number and uses underscores instead
-//of dots. E.g. platform for Firefox will be: 'Intel Mac OS X 10.11'
-//but for Chrome it will be 'Intel Mac OS X 10_11_6'.
-//param platform: - string like "Macintosh; Intel Mac OS X 10.8"
-//return: platform with version number including minor number and formatted
-//    with underscores, e.g. "Macintosh; Intel Mac OS X 10_8_2"
-
-func fixChromeMacPlatform(platform string) (string, error) {
-	ver := strings.Split(platform, "OS X ")[1]
-	build_range := (MACOSX_CHROME_BUILD_RANGE[ver])
-	nBig, err := rand.Int(rand.Reader, big.NewInt(int64(build_range[1])))
-	if err != nil {
-		return "", err
+// Uniform random number in [0, n) from crypto/rand
+func randIntn(n int) (int, error) {
+	var b [8]byte
+	// reject values of the last incomplete range of n numbers
+	limit := ^uint64(0) - ^uint64(0)%uint64(n)
+	for {
+		if _, err := rand.Read(b[:]); err != nil {
+			return 0, err
+		}
+		if v := binary.LittleEndian.Uint64(b[:]); v < limit {
+			return int(v % uint64(n)), nil
+		}
 	}
-	build := int(nBig.Int64())
-	mac_ver := strings.Replace(ver, ".", "_", -1) + "_" + strconv.Itoa(build)
-	return fmt.Sprintf("Macintosh; Intel Mac OS X %s", mac_ver), nil
 }
 
-//Build random platform and oscpu components for given parameters.
-//Returns dict {platform_version, platform, ua_platform, oscpu}
-//platform_version is OS name used in different places
-//ua_platform goes to navigator.platform
-//platform is used in building navigator.userAgent
-//oscpu goes to navigator.oscpu
-
-func buildSystemComponents(deviceType, OSID, navigatorID string) (map[string]string, error) {
-	if !contains([]string{"win", "linux", "mac", "android"}, OSID) {
-		return nil, errors.New("Invalid platform")
-	}
-	var platform string
-	if OSID == "win" {
-		nBig, err := rand.Int(rand.Reader, big.NewInt(int64(len(OS_PLATFORM["win"]))))
-		if err != nil {
-			return nil, err
-		}
-		platform_version := OS_PLATFORM["win"][nBig.Int64()]
-		nBig, err = rand.Int(rand.Reader, big.NewInt(int64(len(OS_CPU["win"]))))
-		if err != nil {
-			return nil, err
-		}
-		cpu := OS_CPU["win"][nBig.Int64()]
-		if cpu != "" {
-			platform = fmt.Sprintf("%s %s", platform_version, cpu)
-		} else {
-			platform = platform_version
-		}
-		return map[string]string{
-			"platform_version": platform_version,
-			"platform":         platform,
-			"ua_platform":      platform,
-			"oscpu":            platform,
-		}, nil
-	}
-	if OSID == "linux" {
-		nBig, err := rand.Int(rand.Reader, big.NewInt(int64(len(OS_CPU["linux"]))))
-		if err != nil {
-			return nil, err
-		}
-		cpu := OS_CPU["linux"][nBig.Int64()]
-		nBig, err = rand.Int(rand.Reader, big.NewInt(int64(len(OS_PLATFORM["linux"]))))
+// Build random system components of the variant: one of its platform
+// versions, then one of the variants of the os for it the config allows,
+// drawn until try accepts one. The choices are recorded in trace if not nil.
+func (r *Registry) buildSystemComponents(cfg *UserAgentConfig, v Variant, intn func(int) (int, error), w *Weights, trace *Trace, try func(System) (bool, error)) (bool, error) {
+	os, ok := r.OS(v.OS)
+	if !ok {
+		return false, errors.New("Invalid platform")
+	}
+	return w.pickUntil(intn, len(v.Platforms), func(i int) float64 { return w.platform(v.Platforms[i]) }, func(i int) (bool, error) {
+		platform_version := v.Platforms[i]
+		systems, err := r.systems(cfg, os, v.DeviceType, platform_version, v.Navigator)
 		if err != nil {
-			return nil, err
+			return false, err
 		}
-		platform_version := OS_PLATFORM["linux"][nBig.Int64()]
-		platform := fmt.Sprintf("%s %s", platform_version, cpu)
-		return map[string]string{
-			"platform_version": platform_version,
-			"platform":         platform,
-			"ua_platform":      platform,
-			"oscpu":            fmt.Sprintf("Linux %s", cpu),
-		}, nil
-	}
-	if OSID == "mac" {
-		nBig, err := rand.Int(rand.Reader, big.NewInt(int64(len(OS_PLATFORM["mac"]))))
-		if err != nil {
-			return nil, err
-		}
-		platform_version := OS_PLATFORM["mac"][nBig.Int64()]
-		platform := platform_version
-		if navigatorID == "chrome" {
-			platform, err = fixChromeMacPlatform(platform)
+		return (*Weights)(nil).pickUntil(intn, systems.n, nil, func(i int) (bool, error) {
+			system, err := os.System(v.DeviceType, platform_version, v.Navigator, systems.at(i))
 			if err != nil {
-				return nil, err
+				return false, err
 			}
-		}
-		return map[string]string{
-			"platform_version": platform_version,
-			"platform":         "MacIntel",
-			"ua_platform":      platform,
-			"oscpu": fmt.Sprintf("Intel Mac OS X %s",
-				strings.Split(platform, " ")[len(strings.Split(platform, " "))-1]),
-		}, nil
-	}
-
-	// OSID could be only "android" here
-	if !contains([]string{"firefox", "chrome"}, navigatorID) {
-		return nil, errors.New("assertion error")
-	}
+			if trace != nil {
+				trace.Platform, trace.Systems, trace.SystemIndex = platform_version, systems.n, i
+				trace.CPU, trace.DeviceID, trace.UAPlatform = system.CPU, system.DeviceID, system.UAPlatform
+				trace.PlatformFixup = ""
+				if f, ok := os.(platformFixer); ok {
+					trace.PlatformFixup = f.platformFixup(v.DeviceType, platform_version, v.Navigator, systems.at(i))
+				}
+			}
+			return try(system)
+		})
+	})
+}
 
-	if !contains([]string{"smartphone", "tablet"}, deviceType) {
-		return nil, errors.New("assertion error")
-	}
-	nBig, err := rand.Int(rand.Reader, big.NewInt(int64(len(OS_PLATFORM["android"]))))
-	if err != nil {
-		return nil, err
-	}
-	platform_version := OS_PLATFORM["android"][nBig.Int64()]
-	var ua_platform, oscpu string
-	if navigatorID == "firefox" {
-		if deviceType == "smartphone" {
-			ua_platform = fmt.Sprintf("%s; Mobile", platform_version)
-		} else if deviceType == "tablet" {
-			ua_platform = fmt.Sprintf("%s; Tablet", platform_version)
-		}
-	} else if navigatorID == "chrome" {
-		nBig, err := rand.Int(rand.Reader, big.NewInt(int64(len(OS_PLATFORM["android"]))))
-		if err != nil {
-			return nil, err
-		}
-		platform_version = OS_PLATFORM["android"][nBig.Int64()]
-		nBig, err = rand.Int(rand.Reader, big.NewInt(int64(len(SMARTPHONE_DEV_IDS))))
+// Build app components of a random build of the browser that runs on
+// the platform version and that the config allows, drawn until try
+// accepts one. The choice is recorded in trace if not nil.
+func (r *Registry) buildAppComponents(OSID, navigatorID, platformVersion string, cfg *UserAgentConfig, intn func(int) (int, error), w *Weights, trace *Trace, try func(App) (bool, error)) (bool, error) {
+	b, ok := r.Browser(navigatorID)
+	if !ok {
+		return false, errors.New("invalid browser")
+	}
+	builds := configBuilds(b, platformVersion, cfg)
+	return w.pickUntil(intn, len(builds), func(i int) float64 { return w.build(navigatorID, builds[i]) }, func(i int) (bool, error) {
+		app, err := b.App(OSID, builds[i])
 		if err != nil {
-			return nil, err
+			return false, err
 		}
-		device_id := SMARTPHONE_DEV_IDS[nBig.Int64()]
-		ua_platform = fmt.Sprintf("Linux; %s; %s", platform_version, device_id)
-		nBig, err = rand.Int(rand.Reader, big.NewInt(int64(len(OS_CPU["android"]))))
-		if err != nil {
-			return nil, err
+		if trace != nil {
+			trace.Builds, trace.Build = len(builds), builds[i].Version
 		}
-		oscpu = fmt.Sprintf("Linux %s", OS_CPU["android"][nBig.Int64()])
-	}
-	return map[string]string{
-		"platform_version": platform_version,
-		"ua_platform":      ua_platform,
-		"platform":         oscpu,
-		"oscpu":            oscpu,
-	}, nil
+		return try(app)
+	})
 }
 
 // contains checks if a string is present in a slice
@@ -456,266 +382,180 @@ func contains(s []string, str string) bool {
 	return false
 }
 
-//Build app features for given os and navigator.
-//Returns dict {name, product_sub, vendor, build_version, build_id}
-
-func buildAppComponents(OSID, navigatorID string) (map[string]string, error) {
-	if !contains([]string{"firefox", "chrome", "ie"}, navigatorID) {
-		return nil, errors.New("invalid browser")
-	}
-	if navigatorID == "firefox" {
-		//fxbuild, err :=
-		build_version, build_id, err := getFirefoxBuild()
-		if err != nil {
-			return nil, err
-		}
-		var geckotrail string
-		if contains([]string{"win", "linux", "mac"}, OSID) {
-			geckotrail = "20100101"
-		} else {
-			geckotrail = build_version
-		}
-		return map[string]string{
-			"name":          "Netscape",
-			"product_sub":   "20100101",
-			"vendor":        "",
-			"build_version": build_version,
-			"build_id":      build_id,
-			"geckotrail":    geckotrail,
-		}, nil
-	}
-	if navigatorID == "chrome" {
-		chromebuild, err := getChromeBuild()
-		if err != nil {
-			return nil, err
-		}
-		return map[string]string{
-			"name":          "Netscape",
-			"product_sub":   "20030107",
-			"vendor":        "Google Inc.",
-			"build_version": chromebuild,
-			"build_id":      "",
-		}, nil
-	}
-	// navigator_id could be only "ie" here
-	iebuild, err := getIEBuild()
-	if err != nil {
-		return nil, err
-	}
-	num_ver, build_version, trident_version := iebuild.NumericVersion, iebuild.StringVersion, iebuild.TridentVersion
-	var app_name string
-	if num_ver >= 11 {
-		app_name = "Netscape"
-	} else {
-		app_name = "Microsoft Internet Explorer"
-	}
-	return map[string]string{
-		"name":            app_name,
-		"product_sub":     "",
-		"vendor":          "",
-		"build_version":   build_version,
-		"build_id":        "",
-		"trident_version": trident_version,
-	}, nil
-}
-
 //Generate something.
 //Long uninformative description: Generate possible choices for the
 //option `opt_name` limited to `opt_value` value with default value
-//as `default_value`
+//as `default_value`. `opt_value` can be nil, a string or a list.
 
-func getOptionChoices(opt_name string, opt_value []string, default_value, all_choices []string) []string {
+func getOptionChoices(opt_name string, opt_value any, default_value, all_choices []string) ([]string, error) {
 	var choices []string
-	choices = opt_value
-	if len(opt_value) == 0 {
+	switch v := opt_value.(type) {
+	case nil:
+	case string:
+		choices = []string{v}
+	case []string:
+		choices = v
+	case OSID:
+		choices = []string{string(v)}
+	case []OSID:
+		choices = idStrings(v)
+	case NavigatorID:
+		choices = []string{string(v)}
+	case []NavigatorID:
+		choices = idStrings(v)
+	default:
+		return nil, fmt.Errorf("option %s must be a string or a list, got %T", opt_name, opt_value)
+	}
+	if len(choices) == 0 {
 		choices = default_value
 	}
-	if contains(opt_value, "all") {
+	if contains(choices, "all") {
 		choices = all_choices
 	}
 	for _, item := range choices {
 		if !contains(all_choices, item) {
-			panic(fmt.Sprintf("Choices of option %s contains invalid item: %s", opt_name, item))
+			return nil, fmt.Errorf("Choices of option %s contains invalid item: %s", opt_name, item)
 		}
 	}
-	return choices
+	return choices, nil
 }
 
-// Select one item from all possible combinations of (device, os, navigator) items.
-func pickConfigIDs(cfg *UserAgentConfig) (string, string, string, error) {
-	var (
-		defaultDevTypes  []string
-		deviceTypeOSKeys []string
-		osNavigatorKeys  []string
-		navigatorOSkeys  []string
-		variants         [][]string
-		deviceType       []string
-		OS               []string
-		navigator        []string
-		osPlatformKeys   []string
-	)
-	for k := range DEVICE_TYPE_OS {
-		deviceTypeOSKeys = append(deviceTypeOSKeys, k)
-	}
-
-	for j := range OS_NAVIGATOR {
-		osNavigatorKeys = append(osNavigatorKeys, j)
-	}
-	for l := range NAVIGATOR_OS {
-		navigatorOSkeys = append(navigatorOSkeys, l)
-	}
-	for k := range OS_PLATFORM {
-		osPlatformKeys = append(osPlatformKeys, k)
-	}
-	if cfg.OS == nil {
-		defaultDevTypes = []string{"desktop"}
-	} else {
-		defaultDevTypes = deviceTypeOSKeys
-
-	}
-	if cfg.DeviceType == nil {
-		deviceType = nil
-	}
-
-	if cfg.OS == nil {
-		OS = nil
-	}
-	if cfg.Navigator == nil {
-		navigator = nil
-	}
-	devTypeChoices := getOptionChoices("device_type", deviceType, defaultDevTypes, deviceTypeOSKeys)
-	osChoices := getOptionChoices("os", OS, osNavigatorKeys, osNavigatorKeys)
-	navChoices := getOptionChoices("navigator", navigator, navigatorOSkeys, navigatorOSkeys)
-	n := product(devTypeChoices, osChoices, navChoices)
-	i := len(devTypeChoices) * len(osChoices) * len(navChoices)
-	fmt.Println(i)
-	for i >= 0 {
-		i--
-		prod := n()
-		if len(prod) != 0 {
-			iter_dev, iter_os, iter_nav := prod[0], prod[1], prod[2]
-			fmt.Println(iter_dev, iter_os, iter_nav)
-			if contains(DEVICE_TYPE_OS[iter_dev], iter_os) && contains(DEVICE_TYPE_NAVIGATOR[iter_dev], iter_nav) && contains(OS_NAVIGATOR[iter_os], iter_nav) {
-				variants = append(variants, []string{iter_dev, iter_os, iter_nav})
-			}
-		}
-	}
-	if len(variants) == 0 {
-		panic("Options device_type, os and navigator conflicts with each other")
-	}
-	nBig, err := rand.Int(rand.Reader, big.NewInt(int64(len(variants))))
+// Select one item from all possible combinations of (device, os, navigator) items,
+// drawn until try accepts one.
+func (r *Registry) pickConfigIDs(cfg *UserAgentConfig, intn func(int) (int, error), w *Weights, try func(Variant) (bool, error)) (bool, error) {
+	variants, err := r.variants(cfg)
 	if err != nil {
-		panic(err)
-	}
-	//fmt.Prinln
-	device_type, os_id, navigator_id := variants[nBig.Int64()][0], variants[nBig.Int64()][1], variants[nBig.Int64()][2]
-
-	if !contains(osPlatformKeys, os_id) {
-		panic("error")
+		return false, err
 	}
-	if !contains(navigatorOSkeys, navigator_id) {
-		panic("error")
-	}
-	if !contains(deviceTypeOSKeys, device_type) {
-		panic("error")
-	}
-
-	return device_type, os_id, navigator_id, nil
+	return w.pickUntil(intn, len(variants), func(i int) float64 { return w.variant(variants[i]) }, func(i int) (bool, error) {
+		return try(variants[i])
+	})
+}
 
+// Generate web navigator's config
+func (g *Generator) generateNavigator(config *UserAgentConfig) (Navigator, error) {
+	return g.generate(config, nil)
 }
-func chooseUATemplate(device_type, navigator_id string, app map[string]string) any {
-	tpl_name := navigator_id
-	if navigator_id == "ie" {
-		if app["build_version"] == "MSIE 11.0" {
-			tpl_name = "ie_11"
-		} else {
-			tpl_name = "ie_less_11"
-		}
+
+// Generate web navigator's config, recording the decisions in trace if not nil
+func (g *Generator) generate(config *UserAgentConfig, trace *Trace) (Navigator, error) {
+	d := g.snapshot()
+	reg, w := d.reg(), d.weights
+	deny, err := config.denyList()
+	if err != nil {
+		return Navigator{}, err
 	}
-	if navigator_id == "chrome" {
-		if device_type == "smartphone" {
-			tpl_name = "chrome_smartphone"
+	if trace != nil {
+		variants, err := reg.Variants(*config)
+		if err != nil {
+			return Navigator{}, err
 		}
-		if device_type == "tablet" {
-			tpl_name = "chrome_tablet"
+		trace.Variants = variants
+	}
+	var nav Navigator
+	ok, err := reg.pickConfigIDs(config, g.intn, w, func(variant Variant) (bool, error) {
+		b, _ := reg.Browser(variant.Navigator)
+		if trace != nil {
+			trace.Variant = variant
 		}
+		return reg.buildSystemComponents(config, variant, g.intn, w, trace, func(system System) (bool, error) {
+			return reg.buildAppComponents(variant.OS, variant.Navigator, system.PlatformVersion, config, g.intn, w, trace, func(app App) (bool, error) {
+				var err error
+				nav, err = g.renderNavigator(variant.DeviceType, variant.OS, b, system, app)
+				if err != nil {
+					return false, err
+				}
+				if deny.denies(nav.UserAgent) {
+					if trace != nil {
+						trace.Denied = append(trace.Denied, nav.UserAgent)
+					}
+					return false, nil
+				}
+				if trace != nil {
+					t, registered, err := g.chooseUATemplate(variant.DeviceType, b, app)
+					if err != nil {
+						return false, err
+					}
+					trace.Template, trace.Registered = t.Name(), registered
+				}
+				return true, nil
+			})
+		})
+	})
+	if err != nil {
+		return Navigator{}, err
+	}
+	if !ok {
+		return Navigator{}, errors.New("Option exclude denies every user agent of the config")
 	}
-	return USERAGENTTEMPLATE[tpl_name]
+	return nav, nil
 }
 
-func build_navigator_app_version(OSID, navigatorID, platformVersion, userAgent string) string {
-	if navigatorID == "firefox" {
-		if OSID == "android" {
-			return fmt.Sprintf("5.0 (%s)", platformVersion)
-		}
-		osToken := map[string]string{
-			"win":   "Windows",
-			"mac":   "Macintosh",
-			"linux": "X11",
-		}[OSID]
-		return fmt.Sprintf("5.0 (%s)", osToken)
-	}
-	// here navigator_id could be only "chrome" and "ie"
-	if !strings.HasPrefix(userAgent, "Mozilla/") {
-		panic("assertion error")
-	}
-	return strings.Split(userAgent, "Mozilla/")[1]
+// Browsers deriving navigator.appVersion from the user agent report the
+// user agents they cannot derive it from, e.g. of a registered template
+type appVersioner interface {
+	appVersion(osID string, system System, userAgent string) (string, error)
 }
 
-// Generate web navigator's config
-func generateNavigator(config *UserAgentConfig) map[string]string {
-	device_type, os_id, navigator_id, _ := pickConfigIDs(config)
-	fmt.Println("device type", device_type, os_id, navigator_id)
-	system, _ := buildSystemComponents(device_type, os_id, navigator_id)
-	fmt.Println(system)
-	app, _ := buildAppComponents(os_id, navigator_id)
-	fmt.Println(app)
-	ua_template := chooseUATemplate(device_type, navigator_id, app)
-	fmt.Println(ua_template)
-	t := template.Must(template.New("letter").Parse(ua_template.(string)))
-	var tpl bytes.Buffer
-	_ = t.Execute(&tpl, uatmpl{
+// navigator.appVersion of the user agent, an error if the browser cannot
+// derive it
+func appVersion(b Browser, osID string, system System, userAgent string) (string, error) {
+	if v, ok := b.(appVersioner); ok {
+		return v.appVersion(osID, system, userAgent)
+	}
+	return b.AppVersion(osID, system, userAgent), nil
+}
+
+// Compile user agent and navigator fields from system and app components.
+func (g *Generator) renderNavigator(device_type, os_id string, b Browser, system System, app App) (Navigator, error) {
+	t, _, err := g.chooseUATemplate(device_type, b, app)
+	if err != nil {
+		return Navigator{}, err
+	}
+	var tpl strings.Builder
+	err = t.Execute(&tpl, uatmpl{
 		system,
 		app,
 	})
+	if err != nil {
+		return Navigator{}, err
+	}
 	user_agent := tpl.String()
-	app_version := build_navigator_app_version(os_id, navigator_id, system["platform_version"], user_agent)
-	return map[string]string{
+	app_version, err := appVersion(b, os_id, system, user_agent)
+	if err != nil {
+		return Navigator{}, fmt.Errorf("template %s: %w", t.Name(), err)
+	}
+	return Navigator{
 		// ids
-		"os_id":        os_id,
-		"navigator_id": navigator_id,
+		DeviceType:  device_type,
+		OSID:        os_id,
+		NavigatorID: b.ID(),
 		// system components
-		"platform": system["platform"],
-		"oscpu":    system["oscpu"],
+		PlatformVersion: system.PlatformVersion,
+		UAPlatform:      system.UAPlatform,
+		CPU:             system.CPU,
+		DeviceID:        system.DeviceID,
+		Platform:        system.Platform,
+		Oscpu:           system.Oscpu,
 		// app components
-		"build_version": app["build_version"],
-		"build_id":      app["build_id"],
-		"app_version":   app_version,
-		"app_name":      app["name"],
-		"app_code_name": "Mozilla",
-		"product":       "Gecko",
-		"product_sub":   app["product_sub"],
-		"vendor":        app["vendor"],
-		"vendor_sub":    "",
+		BuildVersion: app.BuildVersion,
+		BuildID:      app.BuildID,
+		AppVersion:   app_version,
+		AppName:      app.Name,
+		AppCodeName:  "Mozilla",
+		Product:      "Gecko",
+		ProductSub:   app.ProductSub,
+		Vendor:       app.Vendor,
+		VendorSub:    "",
 		// compiled user agent
-		"user_agent": user_agent,
-	}
+		UserAgent: user_agent,
+	}, nil
 }
 
 // Generate HTTP User-Agent header.
 // Returns a string of HTTP header
 func GenerateUserAgent(uaconfig ...UserAgentConfig) string {
-	var cfg UserAgentConfig
-	if len(uaconfig) == 0 {
-		cfg = UserAgentConfig{}
-	} else {
-		cfg = uaconfig[0]
-	}
-	config := generateNavigator(&cfg)
-	if config["user_agent"] == "" {
-		panic("unable to generate user-agent")
-	}
-	return config["user_agent"]
+	return defaultGenerator.GenerateUserAgent(uaconfig...)
 }
 
 /*
@@ -753,38 +593,3 @@ func GenerateNavigatorJS(*, os: None | str = None,navigator: None | str = None,
         "vendorSub": config["vendor_sub"],
         "buildID": config["build_id"],
     }*/
-//This the cartesian product of input iterables. Its python equivalent is
-//itertools.product() function(https://docs.python.org/3/library/itertools.html)*/
-func product(a ...[]string) func() []string {
-
-	if len(a) == 0 {
-		panic("empty input")
-	}
-	p := make([]string, len(a))
-	x := make([]int, len(p))
-	return func() []string {
-		p := p[:len(x)]
-		for i, xi := range x {
-
-			if len(a[i]) > xi {
-				p[i] = a[i][xi]
-			} else {
-				p = nil
-				break
-			}
-
-		}
-		for i := len(x) - 1; i >= 0; i-- {
-			x[i]++
-			if x[i] < len(a) {
-				break
-			}
-			x[i] = 0
-			if i <= 0 {
-				x = x[0:0]
-				break
-			}
-		}
-		return p
-	}
-}
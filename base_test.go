@@ -2,6 +2,75 @@ package useragent
 
 import "testing"
 
-func TestgetIEBuild(t *testing.T) {
+func TestGetIEBuild(t *testing.T) {
+	b, _ := defaultRegistry().Browser("ie")
+	for _, build := range b.Builds("Windows NT 6.1") {
+		app, err := b.App("win", build)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, iebuild := range IE_VERSION {
+			if iebuild.StringVersion == build.Version && app.TridentVersion != iebuild.TridentVersion {
+				t.Errorf("trident version of %s: got %s, want %s", build.Version, app.TridentVersion, iebuild.TridentVersion)
+			}
+		}
+	}
+}
+
+func TestSupportMatrix(t *testing.T) {
+	reg := defaultRegistry()
+	for i := 0; i < 300; i++ {
+		nav := GenerateNavigator()
+		r, ok := supportRange(SUPPORT_MATRIX[nav.NavigatorID], nav.PlatformVersion)
+		if ok && !r.contains(majorVersion(nav.BuildVersion)) {
+			t.Errorf("%s %s on %s is not supported", nav.NavigatorID, nav.BuildVersion, nav.PlatformVersion)
+		}
+	}
+	ie, _ := reg.Browser("ie")
+	for _, build := range ie.Builds("Windows NT 5.1") {
+		if build.Version == "MSIE 11.0" {
+			t.Error("IE 11 on Windows XP is supported")
+		}
+	}
+	if r, ok := supportRange(SUPPORT_MATRIX["chrome"], "Android 4.4.2"); !ok || r.Max != 95 {
+		t.Error("Android 4.4.2 does not use the Android 4.4 range")
+	}
+	mac, _ := reg.OS("mac")
+	chrome, _ := reg.Browser("chrome")
+	if contains(supportedPlatforms(mac, chrome), "Macintosh; Intel Mac OS X 10.9") {
+		t.Error("Chrome 80+ on OS X 10.9 is supported")
+	}
+	all, err := All(UserAgentConfig{Navigator: "firefox", Platform: []string{"Macintosh; Intel Mac OS X 10.8"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for nav := range all {
+		if majorVersion(nav.BuildVersion) > 48 {
+			t.Errorf("Firefox %s on OS X 10.8 is generated", nav.BuildVersion)
+		}
+	}
+}
+
+func BenchmarkGenerateUserAgent(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		GenerateUserAgent()
+	}
+}
+
+func BenchmarkGenerateNavigator(b *testing.B) {
+	cfg := UserAgentConfig{OS: "all"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		GenerateNavigator(cfg)
+	}
+}
 
+func BenchmarkGenerateUserAgentParallel(b *testing.B) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			GenerateUserAgent()
+		}
+	})
 }
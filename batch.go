@@ -0,0 +1,114 @@
+package useragent
+
+import (
+	"fmt"
+)
+
+// BatchOption configures GenerateN.
+type BatchOption func(*batchOptions)
+
+type batchOptions struct {
+	uniqueUA  bool
+	uniqueNav bool
+}
+
+// Unique makes GenerateN return navigators with distinct user agents.
+func Unique() BatchOption {
+	return func(o *batchOptions) { o.uniqueUA = true }
+}
+
+// UniqueNavigator makes GenerateN return distinct navigators, which can
+// share a user agent, e.g. Firefox on Android on different cpus.
+func UniqueNavigator() BatchOption {
+	return func(o *batchOptions) { o.uniqueNav = true }
+}
+
+// GenerateN generates n navigators for the config with the package level
+// generator, see Generator.GenerateN.
+func GenerateN(cfg UserAgentConfig, n int, opts ...BatchOption) ([]Navigator, error) {
+	return defaultGenerator.GenerateN(cfg, n, opts...)
+}
+
+// GenerateN generates n navigators for the config. Without options the
+// navigators are independent, like n calls of GenerateNavigator. With
+// Unique or UniqueNavigator they are drawn without replacement, uniformly
+// from every navigator All yields for the config, and an error is returned
+// if the config does not have n distinct ones. With Unique the error is
+// returned before generating if n is over the number of distinct UAPlatform
+// and build pairs, which bounds the user agents of the config.
+func (g *Generator) GenerateN(cfg UserAgentConfig, n int, opts ...BatchOption) ([]Navigator, error) {
+	var o batchOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("negative count %d", n)
+	}
+	if !o.uniqueUA && !o.uniqueNav {
+		navs := make([]Navigator, 0, n)
+		for len(navs) < n {
+			nav, err := g.generateNavigator(&cfg)
+			if err != nil {
+				return nil, err
+			}
+			navs = append(navs, nav)
+		}
+		return navs, nil
+	}
+
+	s, err := g.reg().space(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	if o.uniqueUA {
+		bound, err := s.userAgentBound()
+		if err != nil {
+			return nil, err
+		}
+		if n > bound {
+			return nil, fmt.Errorf("%d unique user agents requested, config has at most %d", n, bound)
+		}
+	}
+	if n > s.total {
+		return nil, fmt.Errorf("%d unique navigators requested, config has %d", n, s.total)
+	}
+	navs := make([]Navigator, 0, min(n, s.total))
+	// Lazy Fisher-Yates shuffle of the index space, only
+	// the swapped positions are stored
+	swapped := make(map[int]int)
+	at := func(i int) int {
+		if k, ok := swapped[i]; ok {
+			return k
+		}
+		return i
+	}
+	seen := make(map[string]bool)
+	for i := 0; len(navs) < n; i++ {
+		// the indices left cannot make up for the missing navigators
+		if s.total-i < n-len(navs) {
+			return nil, fmt.Errorf("%d unique navigators requested, config allows %d", n, len(navs))
+		}
+		j, err := g.intn(s.total - i)
+		if err != nil {
+			return nil, err
+		}
+		j += i
+		k := at(j)
+		swapped[j] = at(i)
+		nav, err := s.navigator(g, k)
+		if err != nil {
+			return nil, err
+		}
+		if s.deny.denies(nav.UserAgent) {
+			continue
+		}
+		if o.uniqueUA {
+			if seen[nav.UserAgent] {
+				continue
+			}
+			seen[nav.UserAgent] = true
+		}
+		navs = append(navs, nav)
+	}
+	return navs, nil
+}
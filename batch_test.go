@@ -0,0 +1,69 @@
+package useragent
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateN(t *testing.T) {
+	cfg := UserAgentConfig{OS: "android", Navigator: "firefox"}
+	total, _ := Count(cfg)
+	uas := make(map[string]bool)
+	all, _ := All(cfg)
+	for nav := range all {
+		uas[nav.UserAgent] = true
+	}
+	if len(uas) == total {
+		t.Fatal("test needs a config with repeated user agents")
+	}
+
+	navs, err := GenerateN(cfg, total, UniqueNavigator())
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[Navigator]bool)
+	for _, nav := range navs {
+		if seen[nav] {
+			t.Fatalf("duplicate navigator %+v", nav)
+		}
+		seen[nav] = true
+	}
+
+	navs, err = GenerateN(cfg, len(uas), Unique())
+	if err != nil {
+		t.Fatal(err)
+	}
+	seenUA := make(map[string]bool)
+	for _, nav := range navs {
+		if seenUA[nav.UserAgent] {
+			t.Fatalf("duplicate user agent %s", nav.UserAgent)
+		}
+		seenUA[nav.UserAgent] = true
+	}
+
+	// Colliding user agents are counted before generating
+	s, _ := defaultRegistry().space(&cfg)
+	if bound, _ := s.userAgentBound(); bound != len(uas) {
+		t.Errorf("user agent bound %d, config has %d", bound, len(uas))
+	}
+	if _, err := GenerateN(cfg, len(uas)+1, Unique()); err == nil || !strings.Contains(err.Error(), "at most") {
+		t.Errorf("more unique user agents than the config has: %v", err)
+	}
+	if _, err := GenerateN(cfg, total+1, UniqueNavigator()); err == nil {
+		t.Error("more unique navigators than the config has: no error")
+	}
+	if _, err := GenerateN(cfg, 1<<40, Unique()); err == nil || !strings.Contains(err.Error(), "at most") {
+		t.Errorf("huge unique count: %v", err)
+	}
+	if _, err := GenerateN(cfg, 1<<40, UniqueNavigator()); err == nil {
+		t.Error("huge unique navigator count: no error")
+	}
+
+	navs, err = GenerateN(UserAgentConfig{}, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(navs) != 100 {
+		t.Errorf("GenerateN(100) returned %d navigators", len(navs))
+	}
+}
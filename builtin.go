@@ -0,0 +1,357 @@
+package useragent
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Built-in operating systems and browsers, built from the tables
+func builtinComponents(t *tables) []Component {
+	var ie_versions []IEVersion
+	for _, build := range t.builds["ie"] {
+		ie_versions = append(ie_versions, IEVersion{majorVersion(build.version), build.version, build.trident, build.released})
+	}
+	return []Component{
+		&winOS{newBuiltinOS(t, "win")},
+		&macOS{newBuiltinOS(t, "mac"), MACOSX_CHROME_BUILD_RANGE},
+		&linuxOS{newBuiltinOS(t, "linux")},
+		&androidOS{newBuiltinOS(t, "android"), t.devices},
+		&chromeBrowser{newBuiltinBrowser(t, "chrome")},
+		&firefoxBrowser{newBuiltinBrowser(t, "firefox")},
+		&ieBrowser{newBuiltinBrowser(t, "ie"), ie_versions},
+	}
+}
+
+type builtinOS struct {
+	id          string
+	deviceTypes []string
+	platforms   []string
+	cpus        []string
+}
+
+func newBuiltinOS(t *tables, id string) builtinOS {
+	return builtinOS{id, OS_DEVICE_TYPE[id], t.platforms[id], OS_CPU[id]}
+}
+
+func (os *builtinOS) ID() string            { return os.id }
+func (os *builtinOS) DeviceTypes() []string { return os.deviceTypes }
+func (os *builtinOS) Platforms() []string   { return os.platforms }
+func (os *builtinOS) cpuList() []string     { return os.cpus }
+
+type winOS struct{ builtinOS }
+
+func (os *winOS) Variants(deviceType, platformVersion, navigatorID string) int {
+	return len(os.cpus)
+}
+
+func (os *winOS) System(deviceType, platformVersion, navigatorID string, i int) (System, error) {
+	cpu := os.cpus[i]
+	platform := platformVersion
+	if cpu != "" {
+		platform = fmt.Sprintf("%s; %s", platformVersion, cpu)
+	}
+	return System{
+		PlatformVersion: platformVersion,
+		Platform:        platform,
+		UAPlatform:      platform,
+		Oscpu:           platform,
+		CPU:             cpu,
+	}, nil
+}
+
+type linuxOS struct{ builtinOS }
+
+func (os *linuxOS) Variants(deviceType, platformVersion, navigatorID string) int {
+	return len(os.cpus)
+}
+
+func (os *linuxOS) System(deviceType, platformVersion, navigatorID string, i int) (System, error) {
+	cpu := os.cpus[i]
+	platform := fmt.Sprintf("%s %s", platformVersion, cpu)
+	return System{
+		PlatformVersion: platformVersion,
+		Platform:        platform,
+		UAPlatform:      platform,
+		Oscpu:           fmt.Sprintf("Linux %s", cpu),
+		CPU:             cpu,
+	}, nil
+}
+
+type macOS struct {
+	builtinOS
+	chromeBuildRange map[string][]int
+}
+
+// Variants of chrome are the mac minor builds, see fixChromeMacPlatform
+func (os *macOS) Variants(deviceType, platformVersion, navigatorID string) int {
+	if navigatorID != "chrome" {
+		return 1
+	}
+	build_range := os.chromeBuildRange[strings.Split(platformVersion, "OS X ")[1]]
+	if len(build_range) != 2 {
+		return 0
+	}
+	return build_range[1] - build_range[0]
+}
+
+func (os *macOS) System(deviceType, platformVersion, navigatorID string, i int) (System, error) {
+	platform := platformVersion
+	if navigatorID == "chrome" {
+		build_range := os.chromeBuildRange[strings.Split(platformVersion, "OS X ")[1]]
+		platform = fixChromeMacPlatform(platformVersion, build_range[0]+i)
+	}
+	return System{
+		PlatformVersion: platformVersion,
+		Platform:        "MacIntel",
+		UAPlatform:      platform,
+		Oscpu: fmt.Sprintf("Intel Mac OS X %s",
+			strings.Split(platform, " ")[len(strings.Split(platform, " "))-1]),
+	}, nil
+}
+
+func (os *macOS) platformFixup(deviceType, platformVersion, navigatorID string, i int) string {
+	if navigatorID != "chrome" {
+		return ""
+	}
+	build_range := os.chromeBuildRange[strings.Split(platformVersion, "OS X ")[1]]
+	return fmt.Sprintf("mac minor build %d of %d-%d", build_range[0]+i, build_range[0], build_range[1]-1)
+}
+
+//Fix chrome version on mac OS.
+//Chrome on Mac OS adds minor version number and uses underscores instead
+//of dots. E.g. platform for Firefox will be: 'Intel Mac OS X 10.11'
+//but for Chrome it will be 'Intel Mac OS X 10_11_6'.
+//param platform: - string like "Macintosh; Intel Mac OS X 10.8"
+//param build: - minor number from MACOSX_CHROME_BUILD_RANGE
+//return: platform with version number including minor number and formatted
+//    with underscores, e.g. "Macintosh; Intel Mac OS X 10_8_2"
+
+func fixChromeMacPlatform(platform string, build int) string {
+	ver := strings.Split(platform, "OS X ")[1]
+	mac_ver := strings.Replace(ver, ".", "_", -1) + "_" + strconv.Itoa(build)
+	return fmt.Sprintf("Macintosh; Intel Mac OS X %s", mac_ver)
+}
+
+type androidOS struct {
+	builtinOS
+	devIDs map[string]func() DevIDs
+}
+
+// Variants are cpus, and for chrome device ids too
+func (os *androidOS) Variants(deviceType, platformVersion, navigatorID string) int {
+	if navigatorID == "chrome" {
+		return len(os.cpus) * len(os.deviceIDs(deviceType))
+	}
+	return len(os.cpus)
+}
+
+func (os *androidOS) deviceIDs(deviceType string) DevIDs {
+	if load, ok := os.devIDs[deviceType]; ok {
+		return load()
+	}
+	return nil
+}
+
+func (os *androidOS) System(deviceType, platformVersion, navigatorID string, i int) (System, error) {
+	if !contains([]string{"smartphone", "tablet"}, deviceType) {
+		return System{}, errors.New("assertion error")
+	}
+	cpu := os.cpus[i%len(os.cpus)]
+	var ua_platform, device_id string
+	if navigatorID == "chrome" {
+		device_id = os.deviceIDs(deviceType)[i/len(os.cpus)]
+		ua_platform = fmt.Sprintf("Linux; %s; %s", platformVersion, device_id)
+	} else if deviceType == "smartphone" {
+		ua_platform = fmt.Sprintf("%s; Mobile", platformVersion)
+	} else {
+		ua_platform = fmt.Sprintf("%s; Tablet", platformVersion)
+	}
+	oscpu := fmt.Sprintf("Linux %s", cpu)
+	return System{
+		PlatformVersion: platformVersion,
+		Platform:        oscpu,
+		UAPlatform:      ua_platform,
+		Oscpu:           oscpu,
+		CPU:             cpu,
+		DeviceID:        device_id,
+	}, nil
+}
+
+type builtinBrowser struct {
+	id          string
+	deviceTypes []string
+	oses        []string
+	builds      []Build
+	support     map[string]SupportRange
+	templates   map[string]string
+	// Builds by platform version
+	platformBuilds sync.Map
+}
+
+func newBuiltinBrowser(t *tables, id string) builtinBrowser {
+	var builds []Build
+	for _, build := range t.builds[id] {
+		builds = append(builds, Build{build.version, build.released})
+	}
+	return builtinBrowser{
+		id:          id,
+		deviceTypes: NAVIGATOR_DEVICE_TYPE[id],
+		oses:        NAVIGATOR_OS[id],
+		builds:      builds,
+		support:     SUPPORT_MATRIX[id],
+		templates:   t.templates,
+	}
+}
+
+func (b *builtinBrowser) ID() string            { return b.id }
+func (b *builtinBrowser) DeviceTypes() []string { return b.deviceTypes }
+func (b *builtinBrowser) OSes() []string        { return b.oses }
+
+// Builds the platform supports according to SUPPORT_MATRIX
+func (b *builtinBrowser) Builds(platformVersion string) []Build {
+	if builds, ok := b.platformBuilds.Load(platformVersion); ok {
+		return builds.([]Build)
+	}
+	builds := b.builds
+	if r, limited := supportRange(b.support, platformVersion); limited {
+		builds = nil
+		for _, build := range b.builds {
+			if r.contains(majorVersion(build.Version)) {
+				builds = append(builds, build)
+			}
+		}
+	}
+	b.platformBuilds.Store(platformVersion, builds)
+	return builds
+}
+
+// navigator.appVersion of browsers that report the user agent without "Mozilla/"
+func mozillaAppVersion(userAgent string) (string, error) {
+	if !strings.HasPrefix(userAgent, "Mozilla/") {
+		return "", fmt.Errorf("user agent %q does not start with Mozilla/", userAgent)
+	}
+	return strings.Split(userAgent, "Mozilla/")[1], nil
+}
+
+type chromeBrowser struct{ builtinBrowser }
+
+func (b *chromeBrowser) App(osID string, build Build) (App, error) {
+	return App{
+		Name:         "Netscape",
+		ProductSub:   "20030107",
+		Vendor:       "Google Inc.",
+		BuildVersion: build.Version,
+	}, nil
+}
+
+func (b *chromeBrowser) Template(deviceType string, app App) (string, string) {
+	tpl_name := "chrome"
+	if deviceType == "smartphone" {
+		tpl_name = "chrome_smartphone"
+	}
+	if deviceType == "tablet" {
+		tpl_name = "chrome_tablet"
+	}
+	return tpl_name, b.templates[tpl_name]
+}
+
+func (b *chromeBrowser) AppVersion(osID string, system System, userAgent string) string {
+	app_version, err := b.appVersion(osID, system, userAgent)
+	if err != nil {
+		panic(err)
+	}
+	return app_version
+}
+
+func (b *chromeBrowser) appVersion(osID string, system System, userAgent string) (string, error) {
+	return mozillaAppVersion(userAgent)
+}
+
+type firefoxBrowser struct{ builtinBrowser }
+
+func (b *firefoxBrowser) App(osID string, build Build) (App, error) {
+	var geckotrail string
+	if contains([]string{"win", "linux", "mac"}, osID) {
+		geckotrail = "20100101"
+	} else {
+		geckotrail = build.Version
+	}
+	return App{
+		Name:         "Netscape",
+		ProductSub:   "20100101",
+		BuildVersion: build.Version,
+		BuildID:      build.Version,
+		GeckoTrail:   geckotrail,
+	}, nil
+}
+
+func (b *firefoxBrowser) Template(deviceType string, app App) (string, string) {
+	return "firefox", b.templates["firefox"]
+}
+
+func (b *firefoxBrowser) AppVersion(osID string, system System, userAgent string) string {
+	if osID == "android" {
+		return fmt.Sprintf("5.0 (%s)", system.PlatformVersion)
+	}
+	var osToken string
+	switch osID {
+	case "win":
+		osToken = "Windows"
+	case "mac":
+		osToken = "Macintosh"
+	case "linux":
+		osToken = "X11"
+	}
+	return "5.0 (" + osToken + ")"
+}
+
+type ieBrowser struct {
+	builtinBrowser
+	versions []IEVersion
+}
+
+func (b *ieBrowser) App(osID string, build Build) (App, error) {
+	var iebuild *IEVersion
+	for i := range b.versions {
+		if b.versions[i].StringVersion == build.Version {
+			iebuild = &b.versions[i]
+		}
+	}
+	if iebuild == nil {
+		return App{}, fmt.Errorf("unknown IE version: %s", build.Version)
+	}
+	var app_name string
+	if iebuild.NumericVersion >= 11 {
+		app_name = "Netscape"
+	} else {
+		app_name = "Microsoft Internet Explorer"
+	}
+	return App{
+		Name:           app_name,
+		BuildVersion:   build.Version,
+		TridentVersion: iebuild.TridentVersion,
+	}, nil
+}
+
+func (b *ieBrowser) Template(deviceType string, app App) (string, string) {
+	tpl_name := "ie_less_11"
+	if app.BuildVersion == "MSIE 11.0" {
+		tpl_name = "ie_11"
+	}
+	return tpl_name, b.templates[tpl_name]
+}
+
+func (b *ieBrowser) AppVersion(osID string, system System, userAgent string) string {
+	app_version, err := b.appVersion(osID, system, userAgent)
+	if err != nil {
+		panic(err)
+	}
+	return app_version
+}
+
+func (b *ieBrowser) appVersion(osID string, system System, userAgent string) (string, error) {
+	return mozillaAppVersion(userAgent)
+}
@@ -0,0 +1,198 @@
+package useragent
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Finding is a contradiction between parts of a browser identity,
+// reported by Check.
+type Finding struct {
+	// Rule is the id of the violated rule, e.g. "ie-non-windows"
+	Rule string
+	// Message describes the contradiction
+	Message string
+}
+
+func (f Finding) String() string {
+	return f.Rule + ": " + f.Message
+}
+
+// Value of the Sec-CH-UA-Platform client hint for an os id
+var clientHintPlatform = map[string]string{
+	"win":     "Windows",
+	"mac":     "macOS",
+	"linux":   "Linux",
+	"android": "Android",
+}
+
+var reClientHintBrand = regexp.MustCompile(`"([^"]*)"\s*;\s*v="([^"]*)"`)
+
+// Check reports contradictions between a user agent, the request headers
+// sent with it and the navigator object of the same browser, like the ones
+// bot-detection systems look for. headers and nav are optional. A user
+// agent Parse does not understand is reported as the single finding
+// "unrecognized-ua". Navigators produced by the generator have no findings.
+//
+// Rules:
+//   - ie-non-windows: Internet Explorer on an OS other than Windows
+//   - mobile-on-desktop: "Mobile" token with a desktop platform
+//   - mac-chrome-platform: Chrome on mac with a platform Chrome does not
+//     report, e.g. dotted or outside MACOSX_CHROME_BUILD_RANGE
+//   - header-user-agent: User-Agent header differs from ua
+//   - client-hints-browser: client hints sent by a browser without them
+//   - client-hints-platform: Sec-CH-UA-Platform differs from the UA platform
+//   - client-hints-mobile: Sec-CH-UA-Mobile differs from the UA
+//   - client-hints-version: Sec-CH-UA brand version differs from the UA
+//   - navigator-user-agent: navigator.userAgent differs from ua
+//   - navigator-ids: navigator os, browser or device type differ from the UA
+//   - navigator-platform, navigator-oscpu: navigator.platform or
+//     navigator.oscpu differ from what the browser reports on the UA platform
+//   - navigator-app: navigator.appName, appVersion, vendor or productSub
+//     differ from what the UA browser reports
+//
+// The oscpu of Chrome and Firefox only differ on mac, on windows and linux
+// a Chrome user agent with the oscpu of Firefox is consistent. The
+// navigator of another browser is still reported there, by navigator-app
+// from its vendor and appVersion.
+func Check(ua string, headers http.Header, nav *Navigator) []Finding {
+	p, err := Parse(ua)
+	if err != nil {
+		return []Finding{{"unrecognized-ua", err.Error()}}
+	}
+	var findings []Finding
+	report := func(rule, format string, args ...any) {
+		findings = append(findings, Finding{rule, fmt.Sprintf(format, args...)})
+	}
+
+	if p.Navigator == "ie" && p.OS != "win" {
+		report("ie-non-windows", "Internet Explorer on %s", p.PlatformVersion)
+	}
+	if p.Mobile && p.DeviceType == "desktop" {
+		report("mobile-on-desktop", "Mobile token on %s", p.PlatformVersion)
+	}
+	if p.Navigator == "chrome" && p.OS == "mac" {
+		if msg := checkChromeMacPlatform(p.UAPlatform); msg != "" {
+			report("mac-chrome-platform", "%s", msg)
+		}
+	}
+
+	if headers != nil {
+		if h := headers.Get("User-Agent"); h != "" && h != ua {
+			report("header-user-agent", "User-Agent header %q differs from %q", h, ua)
+		}
+		checkClientHints(p, headers, report)
+	}
+
+	if nav != nil {
+		checkNavigator(p, ua, nav, report)
+	}
+	return findings
+}
+
+// Returns why a mac platform of a Chrome user agent is impossible,
+// or "" if it is fine.
+func checkChromeMacPlatform(platform string) string {
+	ver := strings.TrimPrefix(platform, "Macintosh; Intel Mac OS X ")
+	if strings.Contains(ver, ".") {
+		return fmt.Sprintf("Chrome reports mac version with underscores, got %q", ver)
+	}
+	parts := strings.Split(ver, "_")
+	if len(parts) != 3 {
+		return fmt.Sprintf("Chrome reports mac version with minor build, got %q", ver)
+	}
+	build_range, ok := MACOSX_CHROME_BUILD_RANGE[parts[0]+"."+parts[1]]
+	if !ok {
+		return fmt.Sprintf("unknown mac version %q", ver)
+	}
+	build, err := strconv.Atoi(parts[2])
+	if err != nil || build < build_range[0] || build >= build_range[1] {
+		return fmt.Sprintf("mac version %q outside of build range %d-%d", ver, build_range[0], build_range[1]-1)
+	}
+	return ""
+}
+
+func checkClientHints(p ParsedUA, headers http.Header, report func(rule, format string, args ...any)) {
+	brands, platform, mobile := headers.Get("Sec-CH-UA"), headers.Get("Sec-CH-UA-Platform"), headers.Get("Sec-CH-UA-Mobile")
+	if brands == "" && platform == "" && mobile == "" {
+		return
+	}
+	if p.Navigator != "chrome" {
+		report("client-hints-browser", "client hints sent by %s", p.Navigator)
+		return
+	}
+	if platform != "" {
+		if got, want := strings.Trim(platform, `"`), clientHintPlatform[p.OS]; got != want {
+			report("client-hints-platform", "Sec-CH-UA-Platform %q on %s", got, p.PlatformVersion)
+		}
+	}
+	if mobile != "" {
+		want := "?0"
+		if p.Mobile {
+			want = "?1"
+		}
+		if mobile != want {
+			report("client-hints-mobile", "Sec-CH-UA-Mobile %s, want %s", mobile, want)
+		}
+	}
+	major := strconv.Itoa(majorVersion(p.Version))
+	for _, m := range reClientHintBrand.FindAllStringSubmatch(brands, -1) {
+		if (m[1] == "Google Chrome" || m[1] == "Chromium") && m[2] != major {
+			report("client-hints-version", "Sec-CH-UA brand %q version %s, UA has %s", m[1], m[2], major)
+		}
+	}
+}
+
+func checkNavigator(p ParsedUA, ua string, nav *Navigator, report func(rule, format string, args ...any)) {
+	if nav.UserAgent != "" && nav.UserAgent != ua {
+		report("navigator-user-agent", "navigator.userAgent %q differs from %q", nav.UserAgent, ua)
+	}
+	if (nav.OSID != "" && nav.OSID != p.OS) ||
+		(nav.NavigatorID != "" && nav.NavigatorID != p.Navigator) ||
+		(nav.DeviceType != "" && nav.DeviceType != p.DeviceType) {
+		report("navigator-ids", "navigator is %s/%s/%s, UA is %s/%s/%s",
+			nav.DeviceType, nav.OSID, nav.NavigatorID, p.DeviceType, p.OS, p.Navigator)
+	}
+
+	var platform, oscpu []string
+	switch p.OS {
+	case "win":
+		platform, oscpu = []string{p.UAPlatform}, []string{p.UAPlatform}
+	case "linux":
+		platform, oscpu = []string{p.UAPlatform}, []string{"Linux " + p.CPU}
+	case "mac":
+		ver := p.UAPlatform[strings.LastIndex(p.UAPlatform, " ")+1:]
+		platform, oscpu = []string{"MacIntel"}, []string{"Intel Mac OS X " + ver}
+	case "android":
+		for _, cpu := range OS_CPU["android"] {
+			oscpu = append(oscpu, "Linux "+cpu)
+		}
+		platform = oscpu
+	}
+	if !contains(platform, nav.Platform) {
+		report("navigator-platform", "navigator.platform %q on %s", nav.Platform, p.UAPlatform)
+	}
+	if !contains(oscpu, nav.Oscpu) {
+		report("navigator-oscpu", "navigator.oscpu %q on %s %s", nav.Oscpu, p.Navigator, p.UAPlatform)
+	}
+
+	b, ok := defaultRegistry().Browser(p.Navigator)
+	if !ok {
+		return
+	}
+	app, err := b.App(p.OS, Build{Version: p.Version})
+	if err != nil {
+		return
+	}
+	if nav.AppName != app.Name || nav.Vendor != app.Vendor || nav.ProductSub != app.ProductSub {
+		report("navigator-app", "navigator appName %q, vendor %q, productSub %q on %s %s",
+			nav.AppName, nav.Vendor, nav.ProductSub, p.Navigator, p.Version)
+	}
+	app_version := b.AppVersion(p.OS, System{PlatformVersion: p.PlatformVersion}, ua)
+	if nav.AppVersion != app_version {
+		report("navigator-app", "navigator.appVersion %q, want %q", nav.AppVersion, app_version)
+	}
+}
@@ -0,0 +1,95 @@
+package useragent
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCheckGenerated(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		nav := GenerateNavigator()
+		if findings := Check(nav.UserAgent, nil, &nav); len(findings) != 0 {
+			t.Errorf("%s: %v", nav.UserAgent, findings)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		ua      string
+		headers http.Header
+		nav     *Navigator
+		rule    string
+	}{
+		{
+			ua:   "Mozilla/5.0 (compatible; MSIE 9.0; X11; Linux x86_64; Trident/5.0)",
+			rule: "ie-non-windows",
+		},
+		{
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/86.0.4240.75 Mobile Safari/537.36",
+			rule: "mobile-on-desktop",
+		},
+		{
+			ua:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_12_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/86.0.4240.75 Safari/537.36",
+			rule: "mac-chrome-platform",
+		},
+		{
+			ua:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/86.0.4240.75 Safari/537.36",
+			headers: http.Header{"Sec-Ch-Ua-Platform": {`"macOS"`}},
+			rule:    "client-hints-platform",
+		},
+		{
+			ua: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_11_2) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/86.0.4240.75 Safari/537.36",
+			nav: &Navigator{
+				Platform:   "MacIntel",
+				Oscpu:      "Intel Mac OS X 10.11",
+				AppName:    "Netscape",
+				AppVersion: "5.0 (Macintosh; Intel Mac OS X 10_11_2) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/86.0.4240.75 Safari/537.36",
+				Vendor:     "Google Inc.",
+				ProductSub: "20030107",
+			},
+			rule: "navigator-oscpu",
+		},
+		{
+			ua:   "curl/7.68.0",
+			rule: "unrecognized-ua",
+		},
+	}
+	for _, tt := range tests {
+		findings := Check(tt.ua, tt.headers, tt.nav)
+		if len(findings) != 1 || findings[0].Rule != tt.rule {
+			t.Errorf("%s: got %v, want %s", tt.ua, findings, tt.rule)
+		}
+	}
+}
+
+func TestCheckOtherBrowserNavigator(t *testing.T) {
+	for _, os := range []string{"win", "linux"} {
+		chrome, err := renderNavigatorFor("desktop", os, "chrome", OS_PLATFORM[os][0], "86.0.4240.75")
+		if err != nil {
+			t.Fatal(err)
+		}
+		firefox, err := renderNavigatorFor("desktop", os, "firefox", OS_PLATFORM[os][0], "50.0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The oscpu of firefox is the one of chrome
+		nav := chrome
+		nav.Oscpu = firefox.Oscpu
+		if findings := Check(chrome.UserAgent, nil, &nav); len(findings) != 0 {
+			t.Errorf("%s: chrome with the oscpu of firefox: %v", os, findings)
+		}
+		// The firefox navigator differs by vendor and appVersion
+		nav = firefox
+		nav.UserAgent, nav.NavigatorID = chrome.UserAgent, "chrome"
+		findings := Check(chrome.UserAgent, nil, &nav)
+		if len(findings) == 0 {
+			t.Errorf("%s: chrome with a firefox navigator: no findings", os)
+		}
+		for _, f := range findings {
+			if f.Rule != "navigator-app" {
+				t.Errorf("%s: chrome with a firefox navigator: %v", os, f)
+			}
+		}
+	}
+}
@@ -0,0 +1,492 @@
+// Command useragent generates user agents and navigators from the shell.
+//
+// Usage:
+//
+//	useragent [flags]
+//
+// Flags:
+//
+//	-os list          os ids, e.g. "win,mac" or "all"
+//	-navigator list   browser ids, e.g. "chrome" or "all"
+//	-device-type list device types, e.g. "desktop,smartphone" or "all"
+//	-platform list    platform versions, e.g. "Windows NT 10.0"
+//	-n count          number of user agents, default 1
+//	-seed int         seed for reproducible output
+//	-as-of date       only builds released by the date, YYYY-MM-DD
+//	-format name      lines (default), json, ndjson or csv
+//	-weights file     sampling weights, see the learn subcommand
+//	-data file        data pack over the embedded data, see useragent.DataPack
+//	-exclude-navigator list, -exclude-platform list, -exclude-cpu list,
+//	-exclude-device-id list
+//	                  browser ids, platform versions, cpus and device ids
+//	                  never generated
+//	-deny file        user agents never generated, one per line
+//	-deny-pattern re  regular expression of user agents never generated
+//	-explain          print the decisions behind each user agent instead,
+//	                  see useragent.Trace, as text or with -format json or
+//	                  ndjson the navigators with their trace
+//
+// Lists are comma separated. The lines format prints user agents, the
+// others print navigators with their user agent.
+//
+// Subcommands:
+//
+//	useragent serve [-addr host:port] [-config file] [-data file [-watch interval]]
+//	useragent analyze [-format text|json] [file ...]
+//	useragent learn [-ua-list] [file ...]
+//	useragent lint [-data dir]
+//
+// serve runs the HTTP service of useragent.Handler, on localhost:8080 by
+// default, e.g. GET /navigator?os=win&seed=1. The config file, see
+// useragent.Config, then the USERAGENT_ environment variables set the
+// config the query parameters apply to, -data replaces their data pack.
+// With -watch, the data pack is reloaded when it changes; invalid
+// versions are logged and skipped.
+//
+// analyze counts the user agents of access logs in combined log format,
+// standard input if no file is given, by navigator, os, platform version,
+// device type and browser major, see useragent.AnalyzeLog.
+//
+// learn prints the weights of the user agents of access logs, or of lists
+// of user agents with -ua-list, as JSON for the -weights flag, see
+// useragent.Weights.
+//
+// lint checks the device files of the data directory, "data" by default,
+// and the version tables of the package, see the lint package, and exits
+// with status 2 if there are problems.
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mwaurawakati/useragent"
+	"github.com/mwaurawakati/useragent/lint"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "useragent:", err)
+		}
+		os.Exit(2)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	if len(args) != 0 {
+		switch args[0] {
+		case "serve":
+			return serve(args[1:])
+		case "analyze":
+			return analyze(args[1:], os.Stdin, stdout)
+		case "learn":
+			return learn(args[1:], os.Stdin, stdout)
+		case "lint":
+			return lintData(args[1:], stdout)
+		}
+	}
+	fs := flag.NewFlagSet("useragent", flag.ContinueOnError)
+	osFlag := fs.String("os", "", "comma separated os ids, e.g. win,mac or all")
+	navigator := fs.String("navigator", "", "comma separated browser ids, e.g. chrome or all")
+	deviceType := fs.String("device-type", "", "comma separated device types, e.g. desktop,smartphone or all")
+	platform := fs.String("platform", "", `comma separated platform versions, e.g. "Windows NT 10.0"`)
+	n := fs.Int("n", 1, "number of user agents")
+	seed := fs.Int64("seed", 0, "seed for reproducible output")
+	asOf := fs.String("as-of", "", "only builds released by the date, YYYY-MM-DD")
+	format := fs.String("format", "lines", "output format: lines, json, ndjson or csv")
+	weights := fs.String("weights", "", "sampling weights file, see learn")
+	data := fs.String("data", "", "data pack file")
+	excludeNavigator := fs.String("exclude-navigator", "", "comma separated browser ids never generated")
+	excludePlatform := fs.String("exclude-platform", "", "comma separated platform versions never generated")
+	excludeCPU := fs.String("exclude-cpu", "", "comma separated cpus never generated, e.g. i686, win: for 32bit windows")
+	excludeDeviceID := fs.String("exclude-device-id", "", "comma separated device ids never generated")
+	deny := fs.String("deny", "", "file of user agents never generated, one per line")
+	denyPattern := fs.String("deny-pattern", "", "regular expression of user agents never generated")
+	explain := fs.Bool("explain", false, "print the decisions behind each user agent")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	switch *format {
+	case "lines", "json", "ndjson":
+	case "csv":
+		if *explain {
+			return errors.New("-explain supports the lines, json and ndjson formats")
+		}
+	default:
+		return fmt.Errorf("unknown format %q, want lines, json, ndjson or csv", *format)
+	}
+	if *n < 0 {
+		return fmt.Errorf("-n: negative count %d", *n)
+	}
+
+	cfg := useragent.UserAgentConfig{
+		DeviceType: list(*deviceType),
+		Platform:   list(*platform),
+		Exclude: useragent.Exclude{
+			Navigators: navigatorIDs(list(*excludeNavigator)),
+			Platforms:  list(*excludePlatform),
+			CPUs:       list(*excludeCPU),
+			DeviceIDs:  list(*excludeDeviceID),
+		},
+	}
+	if *deny != "" {
+		b, err := os.ReadFile(*deny)
+		if err != nil {
+			return err
+		}
+		for _, line := range strings.Split(string(b), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				cfg.Exclude.UserAgents = append(cfg.Exclude.UserAgents, line)
+			}
+		}
+	}
+	if *denyPattern != "" {
+		cfg.Exclude.Patterns = []string{*denyPattern}
+	}
+	if l := list(*osFlag); l != nil {
+		cfg.OS = l
+	}
+	if l := list(*navigator); l != nil {
+		cfg.Navigator = l
+	}
+	if *asOf != "" {
+		t, err := time.Parse("2006-01-02", *asOf)
+		if err != nil {
+			return fmt.Errorf("-as-of: %w", err)
+		}
+		cfg.AsOf = t
+	}
+
+	var g useragent.Generator
+	if err := useDataPack(&g, *data); err != nil {
+		return err
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			g.Seed(*seed)
+		}
+	})
+	if *weights != "" {
+		f, err := os.Open(*weights)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w, err := useragent.ReadWeights(f)
+		if err != nil {
+			return err
+		}
+		g.SetWeights(w)
+	}
+	if *explain {
+		explained := make([]explainedNavigator, *n)
+		for i := range explained {
+			nav, trace, err := g.Explain(cfg)
+			if err != nil {
+				return err
+			}
+			explained[i] = explainedNavigator{nav, trace}
+		}
+		return writeExplained(stdout, *format, explained)
+	}
+	navs, err := g.GenerateN(cfg, *n)
+	if err != nil {
+		return err
+	}
+	return write(stdout, *format, navs)
+}
+
+func serve(args []string) error {
+	srv, err := newServer(args)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "useragent: serving on http://%s\n", srv.Addr)
+	return srv.ListenAndServe()
+}
+
+// Server of the serve flags
+func newServer(args []string) (*http.Server, error) {
+	fs := flag.NewFlagSet("useragent serve", flag.ContinueOnError)
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	config := fs.String("config", "", "config file, see useragent.Config")
+	data := fs.String("data", "", "data pack file")
+	watch := fs.Duration("watch", 0, "interval to check the data pack for changes, 0 to not watch")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() != 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	var opts []useragent.Option
+	if *config != "" {
+		opts = append(opts, useragent.WithConfigFile(*config))
+	}
+	opts = append(opts, useragent.WithEnv())
+	opts = append(opts, func(g *useragent.Generator) error {
+		if *watch > 0 && *data != "" {
+			return g.WatchDataPack(context.Background(), *data, *watch, func(err error) {
+				fmt.Fprintln(os.Stderr, "useragent: reload:", err)
+			})
+		}
+		return useDataPack(g, *data)
+	})
+	g, err := useragent.New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Server{Addr: *addr, Handler: &useragent.Handler{Generator: g}}, nil
+}
+
+// Load the data pack file into the generator, if any
+func useDataPack(g *useragent.Generator, name string) error {
+	if name == "" {
+		return nil
+	}
+	p, err := useragent.LoadDataPackFile(name)
+	if err != nil {
+		return err
+	}
+	g.UseDataPack(p)
+	return nil
+}
+
+func analyze(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("useragent analyze", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format: text or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown format %q, want text or json", *format)
+	}
+	r, closeFiles, err := openInputs(fs.Args(), stdin)
+	if err != nil {
+		return err
+	}
+	defer closeFiles()
+	report, err := useragent.AnalyzeLog(r)
+	if err != nil {
+		return err
+	}
+	if *format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	fmt.Fprintf(stdout, "lines %d, malformed %d, recognized %d, unknown %d\n",
+		report.Lines, report.Malformed, report.Recognized, report.Lines-report.Malformed-report.Recognized)
+	for _, section := range []struct {
+		name   string
+		counts map[string]int
+	}{
+		{"navigator", report.ByNavigator},
+		{"os", report.ByOS},
+		{"platform", report.ByPlatform},
+		{"device type", report.ByDeviceType},
+		{"browser major", report.ByMajor},
+		{"unknown", report.Unknown},
+	} {
+		fmt.Fprintf(stdout, "\n%s:\n", section.name)
+		writeCounts(stdout, section.counts)
+	}
+	return nil
+}
+
+func learn(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("useragent learn", flag.ContinueOnError)
+	uaList := fs.Bool("ua-list", false, "input is a list of user agents, one per line")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	r, closeFiles, err := openInputs(fs.Args(), stdin)
+	if err != nil {
+		return err
+	}
+	defer closeFiles()
+	analyzeInput := useragent.AnalyzeLog
+	if *uaList {
+		analyzeInput = useragent.AnalyzeUAList
+	}
+	report, err := analyzeInput(r)
+	if err != nil {
+		return err
+	}
+	if report.Recognized == 0 {
+		return errors.New("no recognized user agents to learn from")
+	}
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report.Weights())
+}
+
+func lintData(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("useragent lint", flag.ContinueOnError)
+	data := fs.String("data", "data", "data directory")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	problems := lint.Data(os.DirFS(*data))
+	for _, p := range problems {
+		fmt.Fprintln(stdout, p)
+	}
+	if len(problems) != 0 {
+		return fmt.Errorf("%d problems", len(problems))
+	}
+	return nil
+}
+
+// Concatenation of the named files, stdin if there are none
+func openInputs(names []string, stdin io.Reader) (io.Reader, func(), error) {
+	if len(names) == 0 {
+		return stdin, func() {}, nil
+	}
+	var files []*os.File
+	closeFiles := func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}
+	readers := make([]io.Reader, 0, len(names))
+	for _, name := range names {
+		f, err := os.Open(name)
+		if err != nil {
+			closeFiles()
+			return nil, nil, err
+		}
+		files = append(files, f)
+		readers = append(readers, f)
+	}
+	return io.MultiReader(readers...), closeFiles, nil
+}
+
+// Print counts by decreasing count
+func writeCounts(w io.Writer, counts map[string]int) {
+	keys := make([]string, 0, len(counts))
+	total := 0
+	for k, n := range counts {
+		keys = append(keys, k)
+		total += n
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	for _, k := range keys {
+		fmt.Fprintf(w, "%8d %5.1f%%  %s\n", counts[k], 100*float64(counts[k])/float64(total), k)
+	}
+}
+
+// Comma separated list, nil if empty
+func navigatorIDs(ids []string) []useragent.NavigatorID {
+	var l []useragent.NavigatorID
+	for _, id := range ids {
+		l = append(l, useragent.NavigatorID(id))
+	}
+	return l
+}
+
+func list(s string) []string {
+	if s == "" {
+		return nil
+	}
+	items := strings.Split(s, ",")
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+	return items
+}
+
+// A navigator of -explain and its trace
+type explainedNavigator struct {
+	Navigator useragent.Navigator `json:"navigator"`
+	Trace     *useragent.Trace    `json:"trace"`
+}
+
+func writeExplained(w io.Writer, format string, explained []explainedNavigator) error {
+	switch format {
+	case "lines":
+		for _, e := range explained {
+			if _, err := fmt.Fprintf(w, "%s\n%s\n", e.Navigator.UserAgent, e.Trace); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(explained)
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		for _, e := range explained {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown format %q, want lines, json or ndjson", format)
+}
+
+func write(w io.Writer, format string, navs []useragent.Navigator) error {
+	switch format {
+	case "lines":
+		for _, nav := range navs {
+			if _, err := fmt.Fprintln(w, nav.UserAgent); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(navs)
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		for _, nav := range navs {
+			if err := enc.Encode(nav); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv":
+		cw := csv.NewWriter(w)
+		t := reflect.TypeOf(useragent.Navigator{})
+		header := make([]string, t.NumField())
+		for i := range header {
+			header[i] = strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		}
+		cw.Write(header)
+		for _, nav := range navs {
+			v := reflect.ValueOf(nav)
+			record := make([]string, t.NumField())
+			for i := range record {
+				record[i] = v.Field(i).String()
+			}
+			cw.Write(record)
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return fmt.Errorf("unknown format %q, want lines, json, ndjson or csv", format)
+}
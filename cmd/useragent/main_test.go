@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mwaurawakati/useragent"
+)
+
+func TestRun(t *testing.T) {
+	var a, b bytes.Buffer
+	args := []string{"-os", "win,linux", "-navigator", "firefox", "-n", "5", "-seed", "7"}
+	if err := run(args, &a); err != nil {
+		t.Fatal(err)
+	}
+	if err := run(args, &b); err != nil {
+		t.Fatal(err)
+	}
+	if a.String() != b.String() {
+		t.Errorf("same seed, different output:\n%s\n%s", a.String(), b.String())
+	}
+	lines := strings.Split(strings.TrimSpace(a.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("%d lines, want 5", len(lines))
+	}
+	for _, ua := range lines {
+		p, err := useragent.Parse(ua)
+		if err != nil || p.Navigator != "firefox" || (p.OS != "win" && p.OS != "linux") {
+			t.Errorf("user agent %s: %+v %v", ua, p, err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := run([]string{"-os", "linux", "-exclude-navigator", "firefox", "-deny-pattern", `Chrome/8[0-5]\.`, "-n", "20"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	for _, ua := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if p, err := useragent.Parse(ua); err != nil || p.Navigator != "chrome" || !strings.Contains(ua, "Chrome/86.") {
+			t.Errorf("excluded user agent %s", ua)
+		}
+	}
+
+	out.Reset()
+	if err := run([]string{"-os", "mac", "-navigator", "chrome", "-explain"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "variant: desktop/mac/chrome") || !strings.Contains(out.String(), "platform fixup: ") {
+		t.Errorf("explain output:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := run([]string{"-os", "mac", "-navigator", "chrome", "-explain", "-n", "2", "-format", "ndjson"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var e explainedNavigator
+		if err := json.Unmarshal([]byte(line), &e); err != nil || e.Navigator.OSID != "mac" || e.Trace == nil || e.Trace.PlatformFixup == "" {
+			t.Errorf("explain ndjson line %s: %v", line, err)
+		}
+	}
+
+	out.Reset()
+	if err := run([]string{"-n", "3", "-format", "json"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	var navs []useragent.Navigator
+	if err := json.Unmarshal(out.Bytes(), &navs); err != nil || len(navs) != 3 {
+		t.Errorf("json output: %d navigators, %v", len(navs), err)
+	}
+
+	out.Reset()
+	if err := run([]string{"-n", "3", "-format", "ndjson"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var nav useragent.Navigator
+		if err := json.Unmarshal([]byte(line), &nav); err != nil || nav.UserAgent == "" {
+			t.Errorf("ndjson line %s: %v", line, err)
+		}
+	}
+
+	out.Reset()
+	if err := run([]string{"-n", "2", "-format", "csv", "-as-of", "2020-06-01"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || records[0][len(records[0])-1] != "user_agent" {
+		t.Errorf("csv output: %v", records)
+	}
+
+	// Flags are checked before loading files
+	if err := run([]string{"-format", "xml", "-data", "missing.json"}, &out); err == nil || !strings.Contains(err.Error(), "format") {
+		t.Errorf("invalid format with missing data pack: %v", err)
+	}
+	for _, args := range [][]string{
+		{"-format", "xml"},
+		{"-format", "csv", "-explain"},
+		{"-n", "-1"},
+		{"-os", "beos"},
+		{"-as-of", "June"},
+		{"extra"},
+	} {
+		if err := run(args, &out); err == nil {
+			t.Errorf("run %v: no error", args)
+		}
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	log := `127.0.0.1 - - [10/Oct/2020:13:55:36 -0700] "GET / HTTP/1.1" 200 2326 "-" "Mozilla/5.0 (X11; Linux x86_64; rv:50.0) Gecko/20100101 Firefox/50.0"
+127.0.0.1 - - [10/Oct/2020:13:55:37 -0700] "GET / HTTP/1.1" 200 2326 "-" "curl/7.68.0"
+`
+	var out bytes.Buffer
+	if err := analyze(nil, strings.NewReader(log), &out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"recognized 1, unknown 1", "100.0%  firefox", "100.0%  firefox 50", "curl/7.68.0"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output has no %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := analyze([]string{"-format", "json"}, strings.NewReader(log), &out); err != nil {
+		t.Fatal(err)
+	}
+	var report useragent.LogReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil || report.ByOS["linux"] != 1 {
+		t.Errorf("json report %+v: %v", report, err)
+	}
+}
+
+func TestLearn(t *testing.T) {
+	uas := "Mozilla/5.0 (X11; Linux x86_64; rv:50.0) Gecko/20100101 Firefox/50.0\n"
+	var out bytes.Buffer
+	if err := learn([]string{"-ua-list"}, strings.NewReader(uas), &out); err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(t.TempDir(), "weights.json")
+	if err := os.WriteFile(name, out.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := run([]string{"-os", "all", "-n", "20", "-weights", name}, &out); err != nil {
+		t.Fatal(err)
+	}
+	for _, ua := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if !strings.Contains(ua, "Linux") || !strings.HasSuffix(ua, "Firefox/50.0") {
+			t.Errorf("user agent outside of the weights: %s", ua)
+		}
+	}
+
+	if err := learn(nil, strings.NewReader("curl/7.68.0\n"), &out); err == nil {
+		t.Error("learning from no recognized user agents: no error")
+	}
+}
+
+func TestRunDataPack(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "pack.json")
+	pack := `{"schema": 1, "builds": {"firefox": [{"version": "52.0"}]}, "replace": true}`
+	if err := os.WriteFile(name, []byte(pack), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := run([]string{"-navigator", "firefox", "-n", "5", "-data", name}, &out); err != nil {
+		t.Fatal(err)
+	}
+	for _, ua := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if !strings.HasSuffix(ua, "Firefox/52.0") {
+			t.Errorf("user agent not from the data pack: %s", ua)
+		}
+	}
+	if err := run([]string{"-data", filepath.Join(t.TempDir(), "none.json")}, &out); err == nil {
+		t.Error("missing data pack: no error")
+	}
+}
+
+func TestServeConfig(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(name, []byte(`{"os": ["linux"], "navigator": ["firefox"]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(useragent.EnvOS, "")
+	t.Setenv(useragent.EnvNavigator, "chrome")
+	srv, err := newServer([]string{"-config", name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The environment overrides the file, the query both
+	for path, want := range map[string]string{"/ua": "linux/chrome", "/ua?os=win": "win/chrome", "/ua?navigator=firefox": "linux/firefox"} {
+		rec := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		var ua string
+		if err := json.Unmarshal(rec.Body.Bytes(), &ua); err != nil {
+			t.Fatalf("%s: %v: %s", path, err, rec.Body.String())
+		}
+		if p, err := useragent.Parse(ua); err != nil || p.OS+"/"+p.Navigator != want {
+			t.Errorf("%s: %s, want %s", path, ua, want)
+		}
+	}
+
+	if _, err := newServer([]string{"-config", filepath.Join(t.TempDir(), "none.json")}); err == nil {
+		t.Error("missing config: no error")
+	}
+}
+
+func TestLint(t *testing.T) {
+	var out bytes.Buffer
+	if err := lintData([]string{"-data", "../../data"}, &out); err != nil {
+		t.Errorf("%v:\n%s", err, out.String())
+	}
+	if err := lintData([]string{"-data", t.TempDir()}, &out); err == nil {
+		t.Error("empty data directory: no problems")
+	}
+}
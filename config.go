@@ -0,0 +1,210 @@
+package useragent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config is the file form of the options of New, so the generated user
+// agents can change without a code change, e.g.
+//
+//	{
+//	  "os": ["win", "mac"],
+//	  "navigator": ["chrome", "firefox"],
+//	  "as_of": "2021-01-01",
+//	  "versions": {"chrome": {"min": "84"}},
+//	  "exclude": {"platforms": ["Windows NT 5.1"]},
+//	  "weights": "weights.json"
+//	}
+//
+// as_of is a YYYY-MM-DD date, weights and data_pack are file names,
+// relative to the config file.
+// Fields not set leave the options before it unchanged.
+type Config struct {
+	OS               []OSID                  `json:"os,omitempty"`
+	Navigator        []NavigatorID           `json:"navigator,omitempty"`
+	DeviceType       []DeviceType            `json:"device_type,omitempty"`
+	Platform         []string                `json:"platform,omitempty"`
+	AsOf             string                  `json:"as_of,omitempty"`
+	Versions         map[string]VersionRange `json:"versions,omitempty"`
+	PlatformVersions map[string]VersionRange `json:"platform_versions,omitempty"`
+	Exclude          *Exclude                `json:"exclude,omitempty"`
+	Seed             *int64                  `json:"seed,omitempty"`
+	Weights          string                  `json:"weights,omitempty"`
+	DataPack         string                  `json:"data_pack,omitempty"`
+}
+
+// Environment variables of ConfigFromEnv, lists are comma separated
+const (
+	EnvOS         = "USERAGENT_OS"
+	EnvNavigator  = "USERAGENT_NAVIGATOR"
+	EnvDeviceType = "USERAGENT_DEVICE_TYPE"
+	EnvPlatform   = "USERAGENT_PLATFORM"
+	EnvAsOf       = "USERAGENT_AS_OF"
+	EnvSeed       = "USERAGENT_SEED"
+	EnvWeights    = "USERAGENT_WEIGHTS"
+	EnvDataPack   = "USERAGENT_DATA_PACK"
+)
+
+// ReadConfig decodes a config in the JSON format of Config.
+func ReadConfig(r io.Reader) (*Config, error) {
+	var c Config
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	return &c, nil
+}
+
+// LoadConfig reads the config file at path.
+func LoadConfig(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	c, err := ReadConfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	dir := filepath.Dir(path)
+	for _, name := range []*string{&c.Weights, &c.DataPack} {
+		if *name != "" && !filepath.IsAbs(*name) {
+			*name = filepath.Join(dir, *name)
+		}
+	}
+	return c, nil
+}
+
+// ConfigFromEnv returns the config of the USERAGENT_ environment
+// variables, e.g. USERAGENT_OS=win,mac. File names are relative to the
+// working directory.
+func ConfigFromEnv() (*Config, error) {
+	var c Config
+	for _, id := range envList(EnvOS) {
+		c.OS = append(c.OS, OSID(id))
+	}
+	for _, id := range envList(EnvNavigator) {
+		c.Navigator = append(c.Navigator, NavigatorID(id))
+	}
+	for _, t := range envList(EnvDeviceType) {
+		c.DeviceType = append(c.DeviceType, DeviceType(t))
+	}
+	c.Platform = envList(EnvPlatform)
+	c.AsOf = os.Getenv(EnvAsOf)
+	if s := os.Getenv(EnvSeed); s != "" {
+		seed, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", EnvSeed, err)
+		}
+		c.Seed = &seed
+	}
+	c.Weights = os.Getenv(EnvWeights)
+	c.DataPack = os.Getenv(EnvDataPack)
+	return &c, nil
+}
+
+func envList(name string) []string {
+	s := os.Getenv(name)
+	if s == "" {
+		return nil
+	}
+	items := strings.Split(s, ",")
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+	return items
+}
+
+// Option returns the option applying the fields set in the config.
+func (c *Config) Option() Option {
+	return func(g *Generator) error {
+		if c.DataPack != "" {
+			p, err := LoadDataPackFile(c.DataPack)
+			if err != nil {
+				return err
+			}
+			g.UseDataPack(p)
+		}
+		if c.Weights != "" {
+			f, err := os.Open(c.Weights)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w, err := ReadWeights(f)
+			if err != nil {
+				return err
+			}
+			g.SetWeights(w)
+		}
+		var opts []Option
+		if len(c.OS) != 0 {
+			opts = append(opts, WithOS(c.OS...))
+		}
+		if len(c.Navigator) != 0 {
+			opts = append(opts, WithNavigator(c.Navigator...))
+		}
+		if len(c.DeviceType) != 0 {
+			opts = append(opts, WithDeviceType(c.DeviceType...))
+		}
+		if len(c.Platform) != 0 {
+			opts = append(opts, WithPlatform(c.Platform...))
+		}
+		if c.AsOf != "" {
+			t, err := time.Parse("2006-01-02", c.AsOf)
+			if err != nil {
+				return fmt.Errorf("config as_of: %w", err)
+			}
+			opts = append(opts, WithAsOf(t))
+		}
+		if c.Versions != nil {
+			opts = append(opts, WithVersions(c.Versions))
+		}
+		if c.PlatformVersions != nil {
+			opts = append(opts, WithPlatformVersions(c.PlatformVersions))
+		}
+		if c.Exclude != nil {
+			opts = append(opts, WithExclude(*c.Exclude))
+		}
+		if c.Seed != nil {
+			opts = append(opts, WithSeed(*c.Seed))
+		}
+		for _, opt := range opts {
+			if err := opt(g); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// WithConfigFile applies the config file at path, see LoadConfig.
+func WithConfigFile(path string) Option {
+	return func(g *Generator) error {
+		c, err := LoadConfig(path)
+		if err != nil {
+			return err
+		}
+		return c.Option()(g)
+	}
+}
+
+// WithEnv applies the config of the environment, see ConfigFromEnv.
+// Put it after the other options for the environment to override them.
+func WithEnv() Option {
+	return func(g *Generator) error {
+		c, err := ConfigFromEnv()
+		if err != nil {
+			return err
+		}
+		return c.Option()(g)
+	}
+}
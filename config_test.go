@@ -0,0 +1,60 @@
+package useragent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	weights := `{"variants": {"desktop/linux/chrome": 1}}`
+	config := `{
+  "os": ["win", "linux"],
+  "navigator": ["chrome"],
+  "versions": {"chrome": {"min": "85"}},
+  "exclude": {"cpus": ["i686"]},
+  "weights": "weights.json",
+  "seed": 1
+}`
+	if err := os.WriteFile(filepath.Join(dir, "weights.json"), []byte(weights), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "useragent.json")
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	g, err := New(WithConfigFile(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		nav := g.GenerateNavigator()
+		if nav.OSID != "linux" || nav.CPU == "i686" || Version(nav.BuildVersion).Major() < 85 {
+			t.Fatalf("generated %+v", nav)
+		}
+	}
+
+	// The environment overrides the file
+	t.Setenv(EnvOS, "win")
+	t.Setenv(EnvNavigator, " firefox ")
+	g, err = New(WithConfigFile(path), WithEnv())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ua := g.GenerateUserAgent(); !strings.Contains(ua, "Windows") || !strings.Contains(ua, "Firefox") {
+		t.Errorf("generated %s", ua)
+	}
+
+	t.Setenv(EnvSeed, "x")
+	if _, err := New(WithEnv()); err == nil {
+		t.Error("invalid seed: no error")
+	}
+	if _, err := ReadConfig(strings.NewReader(`{"oses": ["win"]}`)); err == nil {
+		t.Error("unknown field: no error")
+	}
+	if _, err := New(WithConfigFile(filepath.Join(dir, "missing.json"))); err == nil {
+		t.Error("missing file: no error")
+	}
+}
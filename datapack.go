@@ -0,0 +1,315 @@
+package useragent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DataPackSchema is the data pack format version this package reads.
+const DataPackSchema = 1
+
+// DataPack is a versioned set of the data the built-in operating systems
+// and browsers are built from, loaded from JSON with ReadDataPack,
+// LoadDataPack or LoadDataPackFile, e.g.
+//
+//	{
+//	  "schema": 1,
+//	  "version": "2021-03",
+//	  "platforms": {"win": ["Windows NT 10.0"]},
+//	  "builds": {"chrome": [{"version": "89.0.4389.82", "released": "2021-03-02"}]},
+//	  "devices": {"tablet": ["SM-T510 Build/PPR1.180610.011"]},
+//	  "templates": {"firefox": "Mozilla/5.0 ({{.System.UAPlatform}}; rv:{{.App.BuildVersion}}) Gecko/{{.App.GeckoTrail}} Firefox/{{.App.BuildVersion}}"},
+//	  "weights": {"variants": {"desktop/win/chrome": 1}}
+//	}
+//
+// Each list of the pack is merged into the embedded one of the same key,
+// OS_PLATFORM, the builds of CHROME_BUILD, FIREFOX_VERSION and IE_VERSION,
+// SmartphoneDevIDs and TabletDevIDs, USERAGENTTEMPLATE, or replaces it
+// if Replace is set. Keys the pack does not have keep the embedded data.
+type DataPack struct {
+	// Schema must be DataPackSchema
+	Schema int `json:"schema"`
+	// Version identifies the data, e.g. "2021-03"
+	Version string `json:"version,omitempty"`
+	// Replace makes the lists of the pack replace the embedded ones
+	// instead of being merged into them
+	Replace bool `json:"replace,omitempty"`
+	// Platforms are platform versions by os id
+	Platforms map[string][]string `json:"platforms,omitempty"`
+	// Builds are builds by navigator id
+	Builds map[string][]PackBuild `json:"builds,omitempty"`
+	// Devices are device ids by device type, "smartphone" or "tablet"
+	Devices map[string][]string `json:"devices,omitempty"`
+	// Templates are User-Agent templates by USERAGENTTEMPLATE name
+	Templates map[string]string `json:"templates,omitempty"`
+	// Weights is the sampling distribution, see Generator.UseDataPack
+	Weights *Weights `json:"weights,omitempty"`
+}
+
+// PackBuild is a browser build of a DataPack.
+type PackBuild struct {
+	// Version is the build version, e.g. "86.0.4240.75" or "MSIE 11.0"
+	Version string `json:"version"`
+	// Released is the release date, YYYY-MM-DD, optional
+	Released string `json:"released,omitempty"`
+	// Trident is the Trident version of IE builds, e.g. "7.0"
+	Trident string `json:"trident,omitempty"`
+}
+
+// Tables the built-in components are built from
+type tables struct {
+	platforms map[string][]string
+	builds    map[string][]tableBuild
+	// loaded on first use, see device.go
+	devices   map[string]func() DevIDs
+	templates map[string]string
+}
+
+type tableBuild struct {
+	version  string
+	released time.Time
+	trident  string
+}
+
+// Tables of the package variables
+func defaultTables() *tables {
+	t := &tables{
+		platforms: make(map[string][]string),
+		builds:    make(map[string][]tableBuild),
+		devices: map[string]func() DevIDs{
+			"smartphone": smartphoneDevIDs,
+			"tablet":     tabletDevIDs,
+		},
+		templates: make(map[string]string),
+	}
+	for os_id, platforms := range OS_PLATFORM {
+		t.platforms[os_id] = platforms
+	}
+	for _, build := range CHROME_BUILD {
+		t.builds["chrome"] = append(t.builds["chrome"], tableBuild{build, CHROME_RELEASE_DATE[majorVersion(build)], ""})
+	}
+	for _, fxvs := range FIREFOX_VERSION {
+		t.builds["firefox"] = append(t.builds["firefox"], tableBuild{fxvs.Version, fxvs.Date, ""})
+	}
+	for _, iebuild := range IE_VERSION {
+		t.builds["ie"] = append(t.builds["ie"], tableBuild{iebuild.StringVersion, iebuild.Date, iebuild.TridentVersion})
+	}
+	for name, tpl := range USERAGENTTEMPLATE {
+		t.templates[name] = tpl.(string)
+	}
+	return t
+}
+
+// Tables of the pack over the package variables
+func (p *DataPack) tables() *tables {
+	t := defaultTables()
+	for os_id, platforms := range p.Platforms {
+		t.platforms[os_id] = mergeList(t.platforms[os_id], platforms, p.Replace)
+	}
+	for nav_id, builds := range p.Builds {
+		var list []tableBuild
+		if !p.Replace {
+			list = t.builds[nav_id]
+		}
+		for _, build := range builds {
+			released, _ := parsePackDate(build.Released)
+			tb := tableBuild{build.Version, released, build.Trident}
+			replaced := false
+			for i := range list {
+				if list[i].version == tb.version {
+					list[i], replaced = tb, true
+				}
+			}
+			if !replaced {
+				list = append(list, tb)
+			}
+		}
+		t.builds[nav_id] = list
+	}
+	for dev, ids := range p.Devices {
+		embedded, ids, replace := t.devices[dev], ids, p.Replace
+		t.devices[dev] = sync.OnceValue(func() DevIDs {
+			var list DevIDs
+			if embedded != nil {
+				list = embedded()
+			}
+			return mergeList(list, ids, replace)
+		})
+	}
+	for name, tpl := range p.Templates {
+		t.templates[name] = tpl
+	}
+	return t
+}
+
+// Items of b appended to the ones of a not in b, or b if replace
+func mergeList(a, b []string, replace bool) []string {
+	if replace {
+		return append([]string(nil), b...)
+	}
+	list := append([]string(nil), a...)
+	for _, item := range b {
+		if !contains(list, item) {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func parsePackDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+var (
+	reNumericVersion = regexp.MustCompile(`^[0-9]+(\.[0-9]+)*$`)
+	reIEVersion      = regexp.MustCompile(`^MSIE [0-9]+\.[0-9]+$`)
+
+	// Prefix of the platform versions of the built-in oses
+	platformPrefix = map[string]string{
+		"win":     "Windows NT ",
+		"mac":     "Macintosh; Intel Mac OS X ",
+		"linux":   "X11; ",
+		"android": "Android ",
+	}
+)
+
+// Validate checks the pack against the schema: known os, navigator,
+// device type and template names, well-formed versions and dates,
+// templates rendering user agents their browser handles, see
+// Generator.RegisterTemplate, and non-negative weights. All problems are
+// reported.
+func (p *DataPack) Validate() error {
+	var errs []error
+	report := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+	if p.Schema != DataPackSchema {
+		report("schema: %d, want %d", p.Schema, DataPackSchema)
+	}
+	for os_id, platforms := range p.Platforms {
+		prefix, ok := platformPrefix[os_id]
+		if !ok {
+			report("platforms: unknown os %q", os_id)
+			continue
+		}
+		for i, platform := range platforms {
+			if len(platform) <= len(prefix) || platform[:len(prefix)] != prefix {
+				report("platforms.%s[%d]: %q does not start with %q", os_id, i, platform, prefix)
+			}
+		}
+	}
+	for nav_id, builds := range p.Builds {
+		re := reNumericVersion
+		switch nav_id {
+		case "chrome", "firefox":
+		case "ie":
+			re = reIEVersion
+		default:
+			report("builds: unknown navigator %q", nav_id)
+			continue
+		}
+		for i, build := range builds {
+			if !re.MatchString(build.Version) {
+				report("builds.%s[%d]: invalid version %q", nav_id, i, build.Version)
+			}
+			if _, err := parsePackDate(build.Released); err != nil {
+				report("builds.%s[%d]: released %q is not a YYYY-MM-DD date", nav_id, i, build.Released)
+			}
+			if nav_id == "ie" && !reNumericVersion.MatchString(build.Trident) {
+				report("builds.ie[%d]: invalid trident version %q", i, build.Trident)
+			}
+		}
+	}
+	for dev, ids := range p.Devices {
+		if dev != "smartphone" && dev != "tablet" {
+			report("devices: unknown device type %q", dev)
+			continue
+		}
+		for i, id := range ids {
+			if id == "" {
+				report("devices.%s[%d]: empty device id", dev, i)
+			}
+		}
+	}
+	for name, text := range p.Templates {
+		if _, ok := USERAGENTTEMPLATE[name]; !ok {
+			report("templates: unknown template %q", name)
+			continue
+		}
+		t, err := parseUATemplate(name, text)
+		if err == nil {
+			err = t.Execute(io.Discard, uatmpl{})
+		}
+		if err == nil {
+			// rendered with built-in components, chrome and ie need
+			// user agents they derive navigator.appVersion from
+			nav_id, _, _ := strings.Cut(name, "_")
+			if b, ok := defaultRegistry().Browser(nav_id); ok {
+				err = defaultRegistry().checkTemplate(t, b, "", name)
+			}
+		}
+		if err != nil {
+			report("templates.%s: %v", name, err)
+		}
+	}
+	if p.Weights != nil {
+		if err := p.Weights.validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ReadDataPack decodes and validates a data pack.
+func ReadDataPack(r io.Reader) (*DataPack, error) {
+	var p DataPack
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("data pack: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		if p.Version != "" {
+			return nil, fmt.Errorf("data pack %s: %w", p.Version, err)
+		}
+		return nil, fmt.Errorf("data pack: %w", err)
+	}
+	return &p, nil
+}
+
+// LoadDataPack reads the data pack file name of fsys.
+func LoadDataPack(fsys fs.FS, name string) (*DataPack, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadDataPack(f)
+}
+
+// LoadDataPackFile reads the data pack file at path.
+func LoadDataPackFile(path string) (*DataPack, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadDataPack(f)
+}
+
+// NewRegistry returns a registry with the built-in operating systems and
+// browsers built from the data of the pack over the embedded data.
+func (p *DataPack) NewRegistry() *Registry {
+	return newRegistry(p.tables())
+}
@@ -0,0 +1,122 @@
+package useragent
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const testDataPack = `{
+  "schema": 1,
+  "version": "test",
+  "platforms": {"win": ["Windows NT 10.0", "Windows NT 11.0"]},
+  "builds": {
+    "chrome": [{"version": "89.0.4389.82", "released": "2021-03-02"}],
+    "ie": [{"version": "MSIE 7.0", "trident": "3.1"}]
+  },
+  "devices": {"tablet": ["Test Tablet Build/1"]},
+  "templates": {"firefox": "Mozilla/5.0 ({{.System.UAPlatform}}; rv:{{.App.BuildVersion}}) Gecko/{{.App.GeckoTrail}} Firefox/{{.App.BuildVersion}} Test"},
+  "weights": {"variants": {"desktop/win/chrome": 1}}
+}`
+
+func TestDataPack(t *testing.T) {
+	fsys := fstest.MapFS{"packs/test.json": {Data: []byte(testDataPack)}}
+	p, err := LoadDataPack(fsys, "packs/test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var g Generator
+	g.UseDataPack(p)
+	seen := make(map[string]bool)
+	for _, cfg := range []UserAgentConfig{
+		{OS: "win"},
+		{DeviceType: []string{"tablet"}, Navigator: "chrome", Platform: []string{"Android 7.0"}},
+	} {
+		all, err := g.All(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for nav := range all {
+			seen[nav.PlatformVersion] = true
+			seen[nav.BuildVersion] = true
+			seen[nav.DeviceID] = true
+			if nav.NavigatorID == "firefox" && !strings.HasSuffix(nav.UserAgent, " Test") {
+				t.Fatalf("firefox template of the pack not used: %s", nav.UserAgent)
+			}
+		}
+	}
+	want := []string{
+		// merged
+		"Windows NT 11.0", "89.0.4389.82", "MSIE 7.0", "Test Tablet Build/1",
+		// embedded
+		"Windows NT 6.1", "86.0.4240.75", "MSIE 11.0",
+	}
+	if ids := TabletDevIDs(); len(ids) != 0 {
+		want = append(want, ids[0])
+	}
+	for _, want := range want {
+		if !seen[want] {
+			t.Errorf("%s not generated", want)
+		}
+	}
+	for i := 0; i < 20; i++ {
+		if nav := g.GenerateNavigator(); nav.OSID != "win" || nav.NavigatorID != "chrome" {
+			t.Fatalf("weights of the pack not used: %s", nav.UserAgent)
+		}
+	}
+
+	// The package level generator keeps the embedded data
+	if n, _ := Count(UserAgentConfig{Platform: []string{"Windows NT 10.0"}, Navigator: "chrome"}); n != len(CHROME_BUILD)*len(OS_CPU["win"]) {
+		t.Errorf("package level count %d changed by the pack", n)
+	}
+
+	p.Replace = true
+	g.UseDataPack(p)
+	variants, err := g.reg().Variants(UserAgentConfig{OS: "all", Navigator: "chrome"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range variants {
+		if v.OS == "win" && len(v.Platforms) != 2 {
+			t.Errorf("replaced win platforms: %v", v.Platforms)
+		}
+	}
+	n, _ := g.Count(UserAgentConfig{OS: "linux", Navigator: "chrome"})
+	if want := len(OS_PLATFORM["linux"]) * len(OS_CPU["linux"]); n != want {
+		t.Errorf("replaced chrome builds: %d linux navigators, want %d", n, want)
+	}
+}
+
+func TestDataPackValidate(t *testing.T) {
+	_, err := ReadDataPack(strings.NewReader(`{
+  "schema": 2,
+  "platforms": {"beos": ["BeOS 5"], "win": ["Win 10"]},
+  "builds": {"chrome": [{"version": "v89", "released": "March"}], "ie": [{"version": "MSIE 12.0"}], "opera": []},
+  "devices": {"watch": []},
+  "templates": {"firefox": "{{.System.Platfrom}}", "safari": ""},
+  "weights": {"majors": {"chrome 86": -1}}
+}`))
+	if err == nil {
+		t.Fatal("invalid data pack: no error")
+	}
+	for _, want := range []string{
+		"schema: 2", `unknown os "beos"`, `platforms.win[0]`, `invalid version "v89"`,
+		`released "March"`, "invalid trident", `unknown navigator "opera"`, `unknown device type "watch"`,
+		"templates.firefox", `unknown template "safari"`, "negative weight",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error has no %q:\n%v", want, err)
+		}
+	}
+
+	// Chrome derives navigator.appVersion after "Mozilla/"
+	_, err = ReadDataPack(strings.NewReader(`{"schema": 1, "templates": {"chrome": "Chrome/{{.App.BuildVersion}}"}}`))
+	if err == nil || !strings.Contains(err.Error(), "templates.chrome") {
+		t.Errorf("chrome template without Mozilla/: %v", err)
+	}
+
+	if _, err := ReadDataPack(strings.NewReader(`{"schema": 1, "os": {}}`)); err == nil {
+		t.Error("unknown field: no error")
+	}
+}
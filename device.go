@@ -1,19 +1,44 @@
 package useragent
 
 import (
-	"embed"
 	"encoding/json"
+	"sync"
 )
 
+// Device ids are parsed once, by the first use. Build with the
+// useragent_nodevices tag to leave them out of the binary, Chrome on
+// Android is not generated then.
 var (
-	//go:embed data
-	f                  embed.FS
-	SMARTPHONE_DEV_IDS = loadSmartPhoneDevIDs()
-	//TABLET_DEV_IDS     = loadPackageJsonData("/data/tablet_dev_id.json")
+	smartphoneDevIDs = sync.OnceValue(func() DevIDs { return loadDevIDs("data/smartphone_dev_id.json") })
+	tabletDevIDs     = sync.OnceValue(func() DevIDs { return loadDevIDs("data/tablet_dev_id.json") })
 )
 
-func loadSmartPhoneDevIDs() DevIDs {
-	file, err := f.ReadFile("data/smartphone_dev_id.json")
+// Deprecated: use SmartphoneDevIDs and TabletDevIDs. The variables are
+// filled at init, empty with the useragent_nodevices tag; changing them
+// does not change the generated user agents.
+var (
+	SMARTPHONE_DEV_IDS = SmartphoneDevIDs()
+	TABLET_DEV_IDS     = TabletDevIDs()
+)
+
+// SmartphoneDevIDs returns the embedded device ids of smartphones,
+// e.g. "D5503 Build/14.6.A.1.236".
+func SmartphoneDevIDs() DevIDs {
+	return smartphoneDevIDs()
+}
+
+// TabletDevIDs returns the embedded device ids of tablets.
+func TabletDevIDs() DevIDs {
+	return tabletDevIDs()
+}
+
+// Embedded device ids of the file, none without device data. A file
+// missing from the embedded data is a build error and panics.
+func loadDevIDs(name string) DevIDs {
+	if !hasDeviceData {
+		return nil
+	}
+	file, err := f.ReadFile(name)
 	if err != nil {
 		panic(err)
 	}
@@ -23,5 +48,4 @@ func loadSmartPhoneDevIDs() DevIDs {
 		panic(err)
 	}
 	return devIDs
-
 }
@@ -0,0 +1,13 @@
+//go:build !useragent_nodevices
+
+package useragent
+
+import "embed"
+
+const hasDeviceData = true
+
+// Only the device id lists, the *_dev_ext.json files they are
+// generated from are not needed at run time
+//
+//go:embed data/smartphone_dev_id.json data/tablet_dev_id.json
+var f embed.FS
@@ -0,0 +1,20 @@
+//go:build !useragent_nodevices
+
+package useragent
+
+import "testing"
+
+func TestDeviceData(t *testing.T) {
+	if len(SmartphoneDevIDs()) == 0 || len(TabletDevIDs()) == 0 {
+		t.Fatal("no embedded device ids")
+	}
+	if len(SMARTPHONE_DEV_IDS) != len(SmartphoneDevIDs()) || len(TABLET_DEV_IDS) != len(TabletDevIDs()) {
+		t.Error("deprecated device id variables differ from the embedded ids")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("loading a device file missing from the embedded data did not panic")
+		}
+	}()
+	loadDevIDs("data/watch_dev_id.json")
+}
@@ -0,0 +1,10 @@
+//go:build useragent_nodevices
+
+package useragent
+
+import "embed"
+
+// No device data, see device.go
+const hasDeviceData = false
+
+var f embed.FS
@@ -0,0 +1,19 @@
+//go:build useragent_nodevices
+
+package useragent
+
+import "testing"
+
+func TestNoDevices(t *testing.T) {
+	if n := len(SmartphoneDevIDs()) + len(TabletDevIDs()) + len(SMARTPHONE_DEV_IDS) + len(TABLET_DEV_IDS); n != 0 {
+		t.Fatalf("%d device ids without device data", n)
+	}
+	if _, err := Variants(UserAgentConfig{OS: "android", Navigator: "chrome"}); err == nil {
+		t.Error("chrome on android without device data")
+	}
+	for i := 0; i < 10; i++ {
+		if nav := GenerateNavigator(UserAgentConfig{OS: "android"}); nav.NavigatorID != "firefox" {
+			t.Fatalf("generated %s", nav.UserAgent)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package useragent
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// Exclude is what a config leaves out of generation. Excluded navigators,
+// platforms, cpus and device ids are never chosen, denied user agents are
+// skipped when the build is chosen: the choices of each step are drawn
+// without replacement until one leads to an allowed user agent, so a
+// config only fails if it denies every user agent it allows.
+type Exclude struct {
+	// Navigators by id, e.g. NavigatorIE
+	Navigators []NavigatorID `json:"navigators,omitempty"`
+	// Platforms by platform version, e.g. "Windows NT 5.1"
+	Platforms []string `json:"platforms,omitempty"`
+	// CPUs of the registered oses, see OS_CPU, e.g. "i686" on every os
+	// or "win:" on one: "" is the cpu of 32bit windows and the only one
+	// of mac, "win:" leaves out 32bit windows only
+	CPUs []string `json:"cpus,omitempty"`
+	// DeviceIDs of android devices, e.g. "SM-G960F Build/R16NW", see
+	// SmartphoneDevIDs and TabletDevIDs
+	DeviceIDs []string `json:"device_ids,omitempty"`
+	// UserAgents are exact User-Agent headers never generated
+	UserAgents []string `json:"user_agents,omitempty"`
+	// Patterns are regular expressions of User-Agent headers never
+	// generated, e.g. `Chrome/8[0-3]\.`
+	Patterns []string `json:"patterns,omitempty"`
+}
+
+// Compiled patterns by source text
+var excludePatterns sync.Map
+
+// User agents the config denies
+type denyList struct {
+	userAgents map[string]bool
+	patterns   []*regexp.Regexp
+}
+
+// Deny list of the config, nil if it denies no user agent
+func (cfg *UserAgentConfig) denyList() (*denyList, error) {
+	ex := &cfg.Exclude
+	if len(ex.UserAgents) == 0 && len(ex.Patterns) == 0 {
+		return nil, nil
+	}
+	d := &denyList{userAgents: make(map[string]bool, len(ex.UserAgents))}
+	for _, ua := range ex.UserAgents {
+		d.userAgents[ua] = true
+	}
+	for _, pattern := range ex.Patterns {
+		re, ok := excludePatterns.Load(pattern)
+		if !ok {
+			compiled, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("Option exclude has an invalid pattern: %w", err)
+			}
+			re, _ = excludePatterns.LoadOrStore(pattern, compiled)
+		}
+		d.patterns = append(d.patterns, re.(*regexp.Regexp))
+	}
+	return d, nil
+}
+
+// Report whether the deny list has the user agent, a nil list has none
+func (d *denyList) denies(userAgent string) bool {
+	if d == nil {
+		return false
+	}
+	if d.userAgents[userAgent] {
+		return true
+	}
+	for _, re := range d.patterns {
+		if re.MatchString(userAgent) {
+			return true
+		}
+	}
+	return false
+}
+
+// systemSet is the system variants of an os for a device type, platform
+// and browser that a config allows
+type systemSet struct {
+	n int
+	// indexes of the allowed variants, nil if none is excluded
+	indexes []int
+}
+
+// Index of the i-th allowed system variant
+func (s systemSet) at(i int) int {
+	if s.indexes == nil {
+		return i
+	}
+	return s.indexes[i]
+}
+
+// Size cap of the system cache, see maxVariantCache. A config has an
+// entry per device type, os, browser and platform.
+const maxSystemCache = 4096
+
+// Operating systems with device ids list them by device type, to check
+// the ids of Exclude
+type deviceIDLister interface {
+	deviceIDs(deviceType string) DevIDs
+}
+
+// Operating systems list their cpus, to check the cpus of Exclude
+// without building every system
+type cpuLister interface {
+	cpuList() []string
+}
+
+// Cpus of the os: listed by built-in oses, found in the system variants
+// of others
+func (r *Registry) osCPUs(os OperatingSystem) ([]string, error) {
+	if l, ok := os.(cpuLister); ok {
+		return l.cpuList(), nil
+	}
+	var cpus []string
+	for _, dev := range os.DeviceTypes() {
+		for _, platform := range os.Platforms() {
+			for _, b := range r.Browsers() {
+				for i := 0; i < os.Variants(dev, platform, b.ID()); i++ {
+					system, err := os.System(dev, platform, b.ID(), i)
+					if err != nil {
+						return nil, err
+					}
+					if !contains(cpus, system.CPU) {
+						cpus = append(cpus, system.CPU)
+					}
+				}
+			}
+		}
+	}
+	return cpus, nil
+}
+
+// Check the cpus and device ids of the exclude are known, cpus of an os
+// are qualified by its id, e.g. "win:"
+func (r *Registry) checkExclude(ex *Exclude) error {
+	if len(ex.CPUs) != 0 {
+		all_cpus := make(map[string]bool)
+		for _, os := range r.OSes() {
+			cpus, err := r.osCPUs(os)
+			if err != nil {
+				return err
+			}
+			for _, cpu := range cpus {
+				all_cpus[cpu] = true
+				all_cpus[os.ID()+":"+cpu] = true
+			}
+		}
+		for _, cpu := range ex.CPUs {
+			if !all_cpus[cpu] {
+				return fmt.Errorf("Option exclude contains invalid cpu: %s", cpu)
+			}
+		}
+	}
+	if len(ex.DeviceIDs) == 0 {
+		return nil
+	}
+	device_ids := make(map[string]bool)
+	for _, os := range r.OSes() {
+		if l, ok := os.(deviceIDLister); ok {
+			for _, dev := range os.DeviceTypes() {
+				for _, id := range l.deviceIDs(dev) {
+					device_ids[id] = true
+				}
+			}
+		}
+	}
+	for _, id := range ex.DeviceIDs {
+		if !device_ids[id] {
+			return fmt.Errorf("Option exclude contains invalid device id: %s", id)
+		}
+	}
+	return nil
+}
+
+// System variants the config allows, cached until the next Register
+// if the config excludes cpus or device ids
+func (r *Registry) systems(cfg *UserAgentConfig, os OperatingSystem, deviceType, platformVersion, navigatorID string) (systemSet, error) {
+	n := os.Variants(deviceType, platformVersion, navigatorID)
+	if len(cfg.Exclude.CPUs) == 0 && len(cfg.Exclude.DeviceIDs) == 0 {
+		return systemSet{n: n}, nil
+	}
+	key := fmt.Sprintf("%s|%s|%s|%s|%s|%s", setKey(cfg.Exclude.CPUs), setKey(cfg.Exclude.DeviceIDs), os.ID(), deviceType, platformVersion, navigatorID)
+	r.variantMu.Lock()
+	s, ok := r.systemCache[key]
+	r.variantMu.Unlock()
+	if ok {
+		return s, nil
+	}
+	s.indexes = []int{}
+	for i := 0; i < n; i++ {
+		system, err := os.System(deviceType, platformVersion, navigatorID, i)
+		if err != nil {
+			return systemSet{}, err
+		}
+		if contains(cfg.Exclude.CPUs, system.CPU) || contains(cfg.Exclude.CPUs, os.ID()+":"+system.CPU) ||
+			system.DeviceID != "" && contains(cfg.Exclude.DeviceIDs, system.DeviceID) {
+			continue
+		}
+		s.indexes = append(s.indexes, i)
+	}
+	s.n = len(s.indexes)
+	r.variantMu.Lock()
+	if r.systemCache == nil || len(r.systemCache) >= maxSystemCache {
+		r.systemCache = make(map[string]systemSet)
+	}
+	r.systemCache[key] = s
+	r.variantMu.Unlock()
+	return s, nil
+}
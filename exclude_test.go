@@ -0,0 +1,163 @@
+package useragent
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestExclude(t *testing.T) {
+	device_ids := TabletDevIDs()[:min(1, len(TabletDevIDs()))]
+	cfg := UserAgentConfig{
+		OS:         "all",
+		DeviceType: []string{"all"},
+		Exclude: Exclude{
+			Navigators: []NavigatorID{NavigatorIE},
+			Platforms:  []string{"Windows NT 5.1"},
+			CPUs:       []string{"i686", "armv7l"},
+			DeviceIDs:  device_ids,
+			Patterns:   []string{`Chrome/8[0-5]\.`},
+		},
+	}
+	re := regexp.MustCompile(cfg.Exclude.Patterns[0])
+	check := func(nav Navigator) {
+		t.Helper()
+		if nav.NavigatorID == "ie" || nav.PlatformVersion == "Windows NT 5.1" || nav.CPU == "i686" || nav.CPU == "armv7l" ||
+			contains(device_ids, nav.DeviceID) || re.MatchString(nav.UserAgent) {
+			t.Fatalf("excluded navigator generated: %+v", nav)
+		}
+	}
+	for i := 0; i < 200; i++ {
+		check(GenerateNavigator(cfg))
+	}
+	navs, err := GenerateN(cfg, 100, Unique())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, nav := range navs {
+		check(nav)
+	}
+	matrix, err := Matrix(cfg, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, nav := range matrix {
+		check(nav)
+	}
+
+	// Denying all but one user agent of a config leaves that one
+	small := UserAgentConfig{OS: "linux", Navigator: "firefox", Platform: []string{"X11; Linux"}}
+	all, err := All(small)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var uas []string
+	for nav := range all {
+		uas = append(uas, nav.UserAgent)
+	}
+	small.Exclude.UserAgents = uas[1:]
+	for i := 0; i < 20; i++ {
+		if ua := GenerateUserAgent(small); ua != uas[0] {
+			t.Fatalf("generated %s", ua)
+		}
+	}
+	if n, err := Count(small); err != nil || n != 1 {
+		t.Errorf("count %d, %v", n, err)
+	}
+	small.Exclude.UserAgents = uas
+	if _, err := GenerateN(small, 1); err == nil {
+		t.Error("every user agent denied: no error")
+	}
+
+	for _, cfg := range []UserAgentConfig{
+		{Exclude: Exclude{Navigators: []NavigatorID{"opera"}}},
+		{Exclude: Exclude{Platforms: []string{"BeOS"}}},
+		{Exclude: Exclude{Patterns: []string{"("}}},
+		{Exclude: Exclude{CPUs: []string{"sparc"}}},
+		{Exclude: Exclude{DeviceIDs: []string{"Nokia 3310"}}},
+		{OS: "win", Exclude: Exclude{CPUs: []string{"", "Win64; x64", "WOW64"}}},
+	} {
+		if _, err := GenerateN(cfg, 1); err == nil {
+			t.Errorf("%+v: no error", cfg.Exclude)
+		}
+	}
+	if nav := GenerateNavigator(UserAgentConfig{OS: "win", Exclude: Exclude{CPUs: []string{""}}}); !strings.Contains(nav.UserAgent, "64") {
+		t.Errorf("32bit windows generated: %s", nav.UserAgent)
+	}
+	// "" is also the only cpu of mac, "win:" only excludes it on windows
+	if _, err := Variants(UserAgentConfig{OS: "mac", Exclude: Exclude{CPUs: []string{""}}}); err == nil {
+		t.Error("mac without its cpu: no error")
+	}
+	win32 := UserAgentConfig{OS: []string{"win", "mac"}, Exclude: Exclude{CPUs: []string{"win:"}}}
+	if _, err := Variants(UserAgentConfig{OS: "mac", Exclude: win32.Exclude}); err != nil {
+		t.Errorf("mac without 32bit windows: %v", err)
+	}
+	for i := 0; i < 50; i++ {
+		if nav := GenerateNavigator(win32); nav.OSID == "win" && nav.CPU == "" {
+			t.Fatalf("32bit windows generated: %s", nav.UserAgent)
+		}
+	}
+	for _, cpu := range []string{"mac:i686", "beos:", "win"} {
+		if _, err := Variants(UserAgentConfig{Exclude: Exclude{CPUs: []string{cpu}}}); err == nil {
+			t.Errorf("cpu %q: no error", cpu)
+		}
+	}
+}
+
+type haikuOS struct{}
+
+func (haikuOS) ID() string            { return "haiku" }
+func (haikuOS) DeviceTypes() []string { return []string{"desktop"} }
+func (haikuOS) Platforms() []string   { return []string{"Haiku R1"} }
+func (haikuOS) Variants(deviceType, platformVersion, navigatorID string) int {
+	return 2
+}
+func (haikuOS) System(deviceType, platformVersion, navigatorID string, i int) (System, error) {
+	cpu := []string{"x86_64", "riscv64"}[i]
+	return System{PlatformVersion: platformVersion, Platform: cpu, UAPlatform: platformVersion + "; " + cpu, CPU: cpu}, nil
+}
+
+func TestExcludeRegistryCPUs(t *testing.T) {
+	// Cpus are the ones of the registered oses
+	r := new(Registry)
+	r.Register(haikuOS{})
+	r.Register(operaBrowser{})
+	for _, cpu := range []string{"riscv64", "haiku:x86_64"} {
+		if err := r.checkExclude(&Exclude{CPUs: []string{cpu}}); err != nil {
+			t.Errorf("cpu %q: %v", cpu, err)
+		}
+	}
+	for _, cpu := range []string{"Win64; x64", "win:"} {
+		if err := r.checkExclude(&Exclude{CPUs: []string{cpu}}); err == nil {
+			t.Errorf("cpu %q of no registered os: no error", cpu)
+		}
+	}
+}
+
+func TestSystemCache(t *testing.T) {
+	r := NewRegistry()
+	a := UserAgentConfig{OS: "linux", Exclude: Exclude{CPUs: []string{"i686", "x86_64"}}}
+	b := UserAgentConfig{OS: "linux", Exclude: Exclude{CPUs: []string{"x86_64", "i686", "i686"}}}
+	if _, err := r.Variants(a); err != nil {
+		t.Fatal(err)
+	}
+	n := len(r.systemCache)
+	if _, err := r.Variants(b); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.systemCache) != n {
+		t.Errorf("same cpus in another order: %d cached systems, want %d", len(r.systemCache), n)
+	}
+
+	// The cache is bounded
+	device_ids := SmartphoneDevIDs()
+	for i := 0; i < len(device_ids) && i < 500; i++ {
+		cfg := UserAgentConfig{OS: "android", Exclude: Exclude{DeviceIDs: device_ids[i : i+1]}}
+		if _, err := r.Variants(cfg); err != nil {
+			t.Fatal(err)
+		}
+		if len(r.systemCache) > maxSystemCache {
+			t.Fatalf("%d cached systems", len(r.systemCache))
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package useragent
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Trace is the decisions that produced a navigator, see Generator.Explain.
+type Trace struct {
+	// Variants are the candidates of the variant choice,
+	// the ones the config allows
+	Variants []Variant `json:"variants"`
+	// Variant is the chosen device type, os and navigator
+	Variant Variant `json:"variant"`
+	// Platform is the chosen platform version of the variant
+	Platform string `json:"platform"`
+	// SystemIndex is the chosen system variant of the ones the config
+	// allows for the platform, Systems their number
+	Systems     int `json:"systems"`
+	SystemIndex int `json:"system_index"`
+	// CPU and DeviceID of the chosen system variant
+	CPU      string `json:"cpu"`
+	DeviceID string `json:"device_id"`
+	// UAPlatform is the platform in the user agent and PlatformFixup how
+	// the os changed it for the navigator, e.g. the mac build of chrome
+	UAPlatform    string `json:"ua_platform"`
+	PlatformFixup string `json:"platform_fixup,omitempty"`
+	// Build is the chosen build of the ones the config allows on the
+	// platform, Builds their number
+	Builds int    `json:"builds"`
+	Build  string `json:"build"`
+	// Template is the name of the User-Agent template, Registered whether
+	// it was set with RegisterTemplate
+	Template   string `json:"template"`
+	Registered bool   `json:"registered"`
+	// Denied are the user agents the config denies that were generated
+	// before the navigator, see Exclude
+	Denied []string `json:"denied,omitempty"`
+}
+
+// Operating systems that change the platform of some navigators
+// can describe how, for Trace.PlatformFixup
+type platformFixer interface {
+	platformFixup(deviceType, platformVersion, navigatorID string, i int) string
+}
+
+// Explain generates a navigator like GenerateNavigator with the package
+// level generator and returns the decisions that produced it.
+func Explain(uaconfig ...UserAgentConfig) (Navigator, *Trace, error) {
+	return defaultGenerator.Explain(uaconfig...)
+}
+
+// Explain generates a navigator like GenerateNavigator and returns the
+// decisions that produced it: the variants the config allows, the chosen
+// variant, platform, system variant and build, and the template. Invalid
+// configs are reported as error.
+func (g *Generator) Explain(uaconfig ...UserAgentConfig) (Navigator, *Trace, error) {
+	cfg := g.config
+	if len(uaconfig) != 0 {
+		cfg = uaconfig[0]
+	}
+	trace := new(Trace)
+	nav, err := g.generate(&cfg, trace)
+	if err != nil {
+		return Navigator{}, nil, err
+	}
+	return nav, trace, nil
+}
+
+// String formats the trace one decision per line.
+func (t *Trace) String() string {
+	var b strings.Builder
+	v := t.Variant
+	fmt.Fprintf(&b, "variant: %s/%s/%s of %d variants\n", v.DeviceType, v.OS, v.Navigator, len(t.Variants))
+	fmt.Fprintf(&b, "platform: %s of %d platforms\n", t.Platform, len(v.Platforms))
+	fmt.Fprintf(&b, "system: %d of %d, cpu %q", t.SystemIndex, t.Systems, t.CPU)
+	if t.DeviceID != "" {
+		fmt.Fprintf(&b, ", device %s", t.DeviceID)
+	}
+	fmt.Fprintf(&b, "\nua platform: %s\n", t.UAPlatform)
+	if t.PlatformFixup != "" {
+		fmt.Fprintf(&b, "platform fixup: %s\n", t.PlatformFixup)
+	}
+	fmt.Fprintf(&b, "build: %s of %d builds\n", t.Build, t.Builds)
+	if t.Registered {
+		fmt.Fprintf(&b, "template: %s, registered\n", t.Template)
+	} else {
+		fmt.Fprintf(&b, "template: %s\n", t.Template)
+	}
+	for _, ua := range t.Denied {
+		fmt.Fprintf(&b, "denied: %s\n", ua)
+	}
+	return b.String()
+}
@@ -0,0 +1,78 @@
+package useragent
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	var g Generator
+	g.Seed(1)
+	for i := 0; i < 20; i++ {
+		nav, trace, err := g.Explain(UserAgentConfig{OS: "all", DeviceType: []string{"all"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := trace.Variant
+		if v.DeviceType != nav.DeviceType || v.OS != nav.OSID || v.Navigator != nav.NavigatorID ||
+			trace.Platform != nav.PlatformVersion || trace.UAPlatform != nav.UAPlatform ||
+			trace.CPU != nav.CPU || trace.DeviceID != nav.DeviceID || trace.Build != nav.BuildVersion {
+			t.Errorf("trace %+v of %+v", trace, nav)
+		}
+		if len(trace.Variants) == 0 || trace.Systems == 0 || trace.Builds == 0 || trace.Template == "" || trace.Registered {
+			t.Errorf("trace %+v", trace)
+		}
+	}
+
+	_, trace, err := g.Explain(UserAgentConfig{OS: "mac", Navigator: "chrome"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(trace.PlatformFixup, "mac minor build ") || !strings.Contains(trace.String(), "platform fixup: ") {
+		t.Errorf("mac chrome trace:\n%s", trace)
+	}
+
+	if err := g.RegisterTemplate("firefox", "", "Mozilla/5.0 ({{.System.UAPlatform}}) Firefox/{{.App.BuildVersion}}"); err != nil {
+		t.Fatal(err)
+	}
+	small := UserAgentConfig{OS: "linux", Navigator: "firefox", Platform: []string{"X11; Linux"}, Versions: map[string]VersionRange{"firefox": {Min: "49"}}}
+	all, err := g.All(small)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var uas []string
+	for nav := range all {
+		uas = append(uas, nav.UserAgent)
+	}
+	small.Exclude.UserAgents = uas[1:]
+	nav, trace, err := g.Explain(small)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nav.UserAgent != uas[0] || trace.Template != "firefox" || !trace.Registered {
+		t.Errorf("trace %+v of %s", trace, nav.UserAgent)
+	}
+	for _, ua := range trace.Denied {
+		if ua == nav.UserAgent {
+			t.Errorf("%s denied", ua)
+		}
+	}
+
+	// chrome and chrome_tablet have the same text, names do not depend
+	// on which one is compiled first
+	if len(TabletDevIDs()) != 0 {
+		for _, dev := range []string{"tablet", "desktop", "tablet"} {
+			_, trace, err := g.Explain(UserAgentConfig{Navigator: "chrome", DeviceType: []string{dev}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := map[string]string{"tablet": "chrome_tablet", "desktop": "chrome"}[dev]; trace.Template != want {
+				t.Errorf("%s template %s, want %s", dev, trace.Template, want)
+			}
+		}
+	}
+
+	if _, _, err := g.Explain(UserAgentConfig{OS: "beos"}); err == nil {
+		t.Error("invalid config: no error")
+	}
+}
@@ -0,0 +1,342 @@
+package useragent
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+)
+
+// Generator generates user agents and navigators. Unlike the package
+// level functions, it can override User-Agent templates. The zero value
+// is ready to use and generates the same user agents as the package level
+// functions. A Generator is safe for concurrent use.
+type Generator struct {
+	mu        sync.RWMutex
+	templates map[templateKey]*template.Template
+	// Registry and weights, swapped as a whole so a generation
+	// uses one snapshot even if they change meanwhile
+	data atomic.Pointer[generatorData]
+	// Source of a seeded generator, crypto/rand is used if nil
+	randMu sync.Mutex
+	rand   *rand.Rand
+	// Config of the options of New
+	config UserAgentConfig
+}
+
+type generatorData struct {
+	// nil is the default registry
+	registry *Registry
+	weights  *Weights
+}
+
+type templateKey struct {
+	navigatorID string
+	deviceType  string
+}
+
+// Generator used by the package level functions
+var defaultGenerator Generator
+
+// Name and text of a browser template: browsers can give different names
+// to the same text, e.g. the chrome and chrome_tablet templates
+type templateSource struct {
+	name, text string
+}
+
+// Functions available in User-Agent templates
+var templateFuncs = template.FuncMap{
+	// major "86.0.4240.75" is "86", major "MSIE 11.0" is "11"
+	"major": func(version string) string {
+		return strconv.Itoa(majorVersion(version))
+	},
+	// underscore "10.11" is "10_11"
+	"underscore": func(s string) string {
+		return strings.ReplaceAll(s, ".", "_")
+	},
+	"replace":    strings.ReplaceAll,
+	"trimPrefix": strings.TrimPrefix,
+}
+
+// Fields of the System and App components by their key in the templates
+// of earlier versions, where they were maps, e.g. {{index .System
+// "ua_platform"}} is {{.System.UAPlatform}}
+var templateKeys = map[string]string{
+	"platform_version": "PlatformVersion",
+	"platform":         "Platform",
+	"ua_platform":      "UAPlatform",
+	"oscpu":            "Oscpu",
+	"name":             "Name",
+	"product_sub":      "ProductSub",
+	"vendor":           "Vendor",
+	"build_version":    "BuildVersion",
+	"build_id":         "BuildID",
+	"geckotrail":       "GeckoTrail",
+	"trident_version":  "TridentVersion",
+}
+
+var reTemplateKey = regexp.MustCompile(`index\s+\.(System|App)\s+"([a-z_]+)"`)
+
+// Parse a User-Agent template, rewriting component keys of earlier
+// versions to their fields
+func parseUATemplate(name, text string) (*template.Template, error) {
+	text = reTemplateKey.ReplaceAllStringFunc(text, func(index string) string {
+		m := reTemplateKey.FindStringSubmatch(index)
+		if field, ok := templateKeys[m[2]]; ok {
+			return "." + m[1] + "." + field
+		}
+		return index
+	})
+	return template.New(name).Funcs(templateFuncs).Parse(text)
+}
+
+// Seed makes the generator deterministic: after the same seed, the same
+// calls with the same registry generate the same navigators. Without
+// Seed, the generator uses crypto/rand.
+func (g *Generator) Seed(seed int64) {
+	g.randMu.Lock()
+	defer g.randMu.Unlock()
+	g.rand = rand.New(rand.NewSource(seed))
+}
+
+// Generator with the templates of g, seeded with seed
+func (g *Generator) seeded(seed int64) *Generator {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	c := &Generator{templates: make(map[templateKey]*template.Template, len(g.templates)), config: g.config}
+	c.data.Store(g.snapshot())
+	for k, t := range g.templates {
+		c.templates[k] = t
+	}
+	c.Seed(seed)
+	return c
+}
+
+// SetRegistry makes the generator combine the operating systems and
+// browsers of r, the ones of the package level functions if r is nil.
+func (g *Generator) SetRegistry(r *Registry) {
+	g.update(func(d *generatorData) { d.registry = r })
+}
+
+// UseDataPack makes the generator use the built-in operating systems and
+// browsers built from the pack, see DataPack.NewRegistry, and the weights
+// of the pack, uniform sampling if it has none. Registry and weights set
+// before are replaced, a reloaded pack does not keep those of the last.
+func (g *Generator) UseDataPack(p *DataPack) {
+	g.setData(p.NewRegistry(), p.Weights)
+}
+
+// Use the registry and weights
+func (g *Generator) setData(r *Registry, w *Weights) {
+	g.update(func(d *generatorData) {
+		d.registry = r
+		d.weights = w
+	})
+}
+
+// Replace the data with a modified copy
+func (g *Generator) update(modify func(*generatorData)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	d := *g.snapshot()
+	modify(&d)
+	g.data.Store(&d)
+}
+
+// Current registry and weights
+func (g *Generator) snapshot() *generatorData {
+	if d := g.data.Load(); d != nil {
+		return d
+	}
+	return &generatorData{}
+}
+
+func (d *generatorData) reg() *Registry {
+	if d.registry == nil {
+		return defaultRegistry()
+	}
+	return d.registry
+}
+
+// Registry the generator combines
+func (g *Generator) reg() *Registry {
+	return g.snapshot().reg()
+}
+
+// Random number in [0, n) from the seeded source if any
+func (g *Generator) intn(n int) (int, error) {
+	g.randMu.Lock()
+	if g.rand == nil {
+		g.randMu.Unlock()
+		return randIntn(n)
+	}
+	defer g.randMu.Unlock()
+	return g.rand.Intn(n), nil
+}
+
+// RegisterTemplate sets the User-Agent template of the navigator on the
+// device type, "" matching every device type, overriding the template of
+// the browser. The template is a text/template executed with fields
+// System and App, the System and App components of the user agent, e.g.
+// {{.System.UAPlatform}} or {{.App.BuildVersion}}. Besides the builtins,
+// it can call:
+//   - major: major version of a build, e.g. "86" for "86.0.4240.75"
+//   - underscore: replace dots with underscores, e.g. "10_11" for "10.11"
+//   - replace: strings.ReplaceAll
+//   - trimPrefix: strings.TrimPrefix
+//
+// Keys of the components of earlier versions, e.g. {{index .System
+// "ua_platform"}}, are accepted for their fields, see USERAGENTTEMPLATE.
+//
+// The template is parsed and checked once here, rendered with the
+// components of a navigator of the browser if it is registered: the user
+// agent must be one the browser derives navigator.appVersion from, e.g.
+// starting with "Mozilla/" for chrome and ie. An invalid template is
+// reported as error and not registered.
+func (g *Generator) RegisterTemplate(navigatorID, deviceType, text string) error {
+	name := navigatorID
+	if deviceType != "" {
+		name += "_" + deviceType
+	}
+	t, err := parseUATemplate(name, text)
+	if err != nil {
+		return err
+	}
+	if err := t.Execute(io.Discard, uatmpl{}); err != nil {
+		return err
+	}
+	if b, ok := g.reg().Browser(navigatorID); ok {
+		if err := g.reg().checkTemplate(t, b, deviceType, ""); err != nil {
+			return err
+		}
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.templates == nil {
+		g.templates = make(map[templateKey]*template.Template)
+	}
+	g.templates[templateKey{navigatorID, deviceType}] = t
+	return nil
+}
+
+// Check the template renders a user agent of the browser on the device
+// type, "" any, the browser can derive navigator.appVersion from. The
+// components are of the first navigator the template is chosen for by
+// name, "" any name; templates no navigator uses are not checked.
+func (r *Registry) checkTemplate(t *template.Template, b Browser, deviceType, name string) error {
+	for _, os := range r.OSes() {
+		if !contains(b.OSes(), os.ID()) {
+			continue
+		}
+		for _, dev := range os.DeviceTypes() {
+			if deviceType != "" && dev != deviceType || !compatible(dev, os, b) {
+				continue
+			}
+			for _, platform := range supportedPlatforms(os, b) {
+				if os.Variants(dev, platform, b.ID()) == 0 {
+					continue
+				}
+				for _, build := range b.Builds(platform) {
+					app, err := b.App(os.ID(), build)
+					if err != nil {
+						continue
+					}
+					if tpl_name, _ := b.Template(dev, app); name != "" && tpl_name != name {
+						continue
+					}
+					system, err := os.System(dev, platform, b.ID(), 0)
+					if err != nil {
+						return err
+					}
+					var ua strings.Builder
+					if err := t.Execute(&ua, uatmpl{system, app}); err != nil {
+						return err
+					}
+					_, err = appVersion(b, os.ID(), system, ua.String())
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Template of the user agent, the registered one if any,
+// otherwise the one of the browser; registered reports which
+func (g *Generator) chooseUATemplate(device_type string, b Browser, app App) (t *template.Template, registered bool, err error) {
+	g.mu.RLock()
+	t, ok := g.templates[templateKey{b.ID(), device_type}]
+	if !ok {
+		t, ok = g.templates[templateKey{b.ID(), ""}]
+	}
+	g.mu.RUnlock()
+	if ok {
+		return t, true, nil
+	}
+	name, text := b.Template(device_type, app)
+	cache := &g.reg().templates
+	if t, ok := cache.Load(templateSource{name, text}); ok {
+		return t.(*template.Template), false, nil
+	}
+	t, err = parseUATemplate(name, text)
+	if err != nil {
+		return nil, false, fmt.Errorf("template %s: %w", name, err)
+	}
+	cache.Store(templateSource{name, text}, t)
+	return t, false, nil
+}
+
+// GenerateUserAgent generates User-Agent HTTP header.
+func (g *Generator) GenerateUserAgent(uaconfig ...UserAgentConfig) string {
+	return g.GenerateNavigator(uaconfig...).UserAgent
+}
+
+// GenerateNavigator generates web navigator's config, with the config of
+// the generator if none is given. It panics if the config is invalid.
+func (g *Generator) GenerateNavigator(uaconfig ...UserAgentConfig) Navigator {
+	cfg := g.config
+	if len(uaconfig) != 0 {
+		cfg = uaconfig[0]
+	}
+	nav, err := g.generateNavigator(&cfg)
+	if err != nil {
+		panic(err)
+	}
+	if nav.UserAgent == "" {
+		panic("unable to generate user-agent")
+	}
+	return nav
+}
+
+// Age returns the navigator aged to the date, see Navigator.Age,
+// rendered with the templates of the generator.
+func (g *Generator) Age(n Navigator, at time.Time) (Navigator, error) {
+	b, ok := g.reg().Browser(n.NavigatorID)
+	if !ok {
+		return Navigator{}, fmt.Errorf("unknown browser: %s", n.NavigatorID)
+	}
+	var newest *Build
+	for _, build := range b.Builds(n.PlatformVersion) {
+		if build.Released.IsZero() || build.Released.After(at) {
+			continue
+		}
+		if newest == nil || compareVersions(build.Version, newest.Version) > 0 {
+			build := build
+			newest = &build
+		}
+	}
+	if newest == nil || compareVersions(newest.Version, n.BuildVersion) <= 0 {
+		return n, nil
+	}
+	app, err := b.App(n.OSID, *newest)
+	if err != nil {
+		return Navigator{}, err
+	}
+	return g.renderNavigator(n.DeviceType, n.OSID, b, n.system(), app)
+}
@@ -0,0 +1,97 @@
+package useragent
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGeneratorRegisterTemplate(t *testing.T) {
+	var g Generator
+	if err := g.RegisterTemplate("chrome", "", `Mozilla/5.0 ({{.System.UAPlatform}}`+"\n"+`{{end}}`); err == nil {
+		t.Error("template with parse error registered")
+	}
+	if err := g.RegisterTemplate("chrome", "", `Mozilla/5.0 ({{.System.Platfrom}})`); err == nil {
+		t.Error("template with unknown field registered")
+	}
+	if err := g.RegisterTemplate("chrome", "", `Chrome/{{.App.BuildVersion}}`); err == nil {
+		t.Error("template without Mozilla/ registered")
+	}
+	// A template failing for some builds only is reported when generating
+	var h Generator
+	if err := h.RegisterTemplate("ie", "", `{{if eq .App.BuildVersion "MSIE 11.0"}}Trident{{else}}Mozilla/4.0{{end}}`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.GenerateN(UserAgentConfig{Navigator: "ie", Versions: map[string]VersionRange{"ie": {Min: "11"}}}, 1); err == nil {
+		t.Error("user agent without Mozilla/ generated")
+	}
+	err := g.RegisterTemplate("chrome", "desktop",
+		`Mozilla/5.0 ({{.System.UAPlatform}}) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/{{major .App.BuildVersion}}.0.0.0 Safari/537.36`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nav := g.GenerateNavigator(UserAgentConfig{})
+	for i := 0; i < 50 && nav.NavigatorID != "chrome"; i++ {
+		nav = g.GenerateNavigator(UserAgentConfig{})
+	}
+	if nav.NavigatorID != "chrome" {
+		t.Skip("no chrome navigator generated")
+	}
+	want := "Chrome/" + strings.SplitN(nav.BuildVersion, ".", 2)[0] + ".0.0.0 Safari"
+	if !strings.Contains(nav.UserAgent, want) {
+		t.Errorf("user agent %q does not contain %q", nav.UserAgent, want)
+	}
+	if !strings.HasPrefix(nav.AppVersion, "5.0 (") {
+		t.Errorf("app version: %q", nav.AppVersion)
+	}
+}
+
+func TestTemplateKeys(t *testing.T) {
+	// Templates of earlier versions indexed the components by key
+	var g Generator
+	err := g.RegisterTemplate("firefox", "", `Mozilla/5.0 ({{index .System "ua_platform"}}; rv:{{index .App "build_version"}}) Gecko/{{index .App "geckotrail"}} Firefox/{{major (index .App "build_version")}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nav := g.GenerateNavigator(UserAgentConfig{Navigator: "firefox"})
+	want := "Mozilla/5.0 (" + nav.UAPlatform + "; rv:" + nav.BuildVersion + ")"
+	if !strings.HasPrefix(nav.UserAgent, want) || !strings.HasSuffix(nav.UserAgent, "Firefox/"+strings.Split(nav.BuildVersion, ".")[0]) {
+		t.Errorf("user agent %s", nav.UserAgent)
+	}
+	if err := g.RegisterTemplate("firefox", "", `Mozilla/5.0 ({{index .System "cpu_name"}})`); err == nil {
+		t.Error("template with unknown key registered")
+	}
+}
+
+func TestGeneratorSeed(t *testing.T) {
+	var g1, g2 Generator
+	g1.Seed(42)
+	g2.Seed(42)
+	cfg := UserAgentConfig{OS: "all"}
+	for i := 0; i < 50; i++ {
+		if n1, n2 := g1.GenerateNavigator(cfg), g2.GenerateNavigator(cfg); n1 != n2 {
+			t.Fatalf("same seed, different navigators:\n%+v\n%+v", n1, n2)
+		}
+	}
+	u1, _ := g1.GenerateN(cfg, 20, Unique())
+	u2, _ := g2.GenerateN(cfg, 20, Unique())
+	for i := range u1 {
+		if u1[i] != u2[i] {
+			t.Fatalf("same seed, different unique navigators:\n%+v\n%+v", u1[i], u2[i])
+		}
+	}
+}
+
+func TestGenerateAsOf(t *testing.T) {
+	asOf := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	cfg := UserAgentConfig{Navigator: []string{"chrome", "firefox"}, AsOf: asOf}
+	for i := 0; i < 100; i++ {
+		nav := GenerateNavigator(cfg)
+		if nav.NavigatorID == "chrome" && majorVersion(nav.BuildVersion) > 83 {
+			t.Fatalf("chrome %s is not released by %s", nav.BuildVersion, asOf.Format("2006-01-02"))
+		}
+	}
+	if _, err := Variants(UserAgentConfig{AsOf: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)}); err == nil {
+		t.Error("no builds released by 2000: no error")
+	}
+}
@@ -0,0 +1,150 @@
+package useragent
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Largest n a Handler generates per request
+const maxHandlerCount = 10000
+
+// Handler serves generated user agents as JSON:
+//   - GET /ua: user agent string
+//   - GET /navigator: Navigator
+//   - GET /persona: object with the navigator and its request headers
+//   - GET /headers: request headers of a navigator, see Headers
+//
+// Query parameters set the fields of the config of the generator, see
+// Generator.Config: os, navigator, device_type and platform take comma
+// separated lists or can be repeated, as_of is a YYYY-MM-DD date. seed
+// makes the response reproducible and n, 1 by default, returns an array
+// of n items. Invalid parameters are answered with status 400 and a JSON
+// object with an "error" field.
+type Handler struct {
+	// Generator generates the user agents, the package level one if nil
+	Generator *Generator
+}
+
+// Persona is a navigator with the request headers of its browser,
+// as served by Handler on /persona.
+type Persona struct {
+	Navigator Navigator   `json:"navigator"`
+	Headers   http.Header `json:"headers"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	var item func(Navigator) any
+	switch r.URL.Path {
+	case "/ua":
+		item = func(nav Navigator) any { return nav.UserAgent }
+	case "/navigator":
+		item = func(nav Navigator) any { return nav }
+	case "/persona":
+		item = func(nav Navigator) any { return Persona{nav, Headers(nav)} }
+	case "/headers":
+		item = func(nav Navigator) any { return Headers(nav) }
+	default:
+		writeJSONError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	q := r.URL.Query()
+	g := h.Generator
+	if g == nil {
+		g = &defaultGenerator
+	}
+	cfg, err := queryConfig(g.Config(), q)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	n := 1
+	if s := q.Get("n"); s != "" {
+		n, err = strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxHandlerCount {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("n must be a number from 1 to %d", maxHandlerCount))
+			return
+		}
+	}
+	if s := q.Get("seed"); s != "" {
+		seed, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "seed must be an integer")
+			return
+		}
+		g = g.seeded(seed)
+	}
+	navs, err := g.GenerateN(cfg, n)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var body any
+	if q.Has("n") {
+		items := make([]any, len(navs))
+		for i, nav := range navs {
+			items[i] = item(nav)
+		}
+		body = items
+	} else {
+		body = item(navs[0])
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// Config of the query parameters of a Handler request over the config
+// of the generator
+func queryConfig(cfg UserAgentConfig, q url.Values) (UserAgentConfig, error) {
+	list := func(name string) []string {
+		var items []string
+		for _, v := range q[name] {
+			for _, item := range strings.Split(v, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+		}
+		return items
+	}
+	if os := list("os"); os != nil {
+		cfg.OS, cfg.OSes = os, nil
+	}
+	if navigator := list("navigator"); navigator != nil {
+		cfg.Navigator, cfg.Navigators = navigator, nil
+	}
+	if device_type := list("device_type"); device_type != nil {
+		cfg.DeviceType, cfg.DeviceTypes = device_type, nil
+	}
+	if platform := list("platform"); platform != nil {
+		cfg.Platform = platform
+	}
+	if s := q.Get("as_of"); s != "" {
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return cfg, fmt.Errorf("as_of must be a YYYY-MM-DD date, got %q", s)
+		}
+		cfg.AsOf = t
+	}
+	return cfg, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
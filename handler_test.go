@@ -0,0 +1,119 @@
+package useragent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	srv := httptest.NewServer(&Handler{})
+	defer srv.Close()
+	get := func(path string, v any) int {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%s: Content-Type %q", path, ct)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		return resp.StatusCode
+	}
+
+	var ua string
+	if status := get("/ua?os=linux&navigator=firefox", &ua); status != http.StatusOK {
+		t.Fatalf("/ua: status %d", status)
+	}
+	if p, err := Parse(ua); err != nil || p.OS != "linux" || p.Navigator != "firefox" {
+		t.Errorf("/ua: %s", ua)
+	}
+
+	var navs1, navs2 []Navigator
+	get("/navigator?os=win,mac&n=5&seed=3", &navs1)
+	get("/navigator?os=win&os=mac&n=5&seed=3", &navs2)
+	if len(navs1) != 5 {
+		t.Fatalf("/navigator n=5: %d navigators", len(navs1))
+	}
+	for i := range navs1 {
+		if navs1[i] != navs2[i] {
+			t.Errorf("same seed, different navigators:\n%+v\n%+v", navs1[i], navs2[i])
+		}
+	}
+
+	var persona Persona
+	get("/persona?device_type=smartphone", &persona)
+	if findings := Check(persona.Navigator.UserAgent, persona.Headers, &persona.Navigator); len(findings) != 0 {
+		t.Errorf("/persona %s: %v", persona.Navigator.UserAgent, findings)
+	}
+
+	var headers http.Header
+	get("/headers?navigator=ie", &headers)
+	if headers.Get("User-Agent") == "" || headers.Get("Accept") == "" {
+		t.Errorf("/headers: %v", headers)
+	}
+
+	for _, path := range []string{
+		"/ua?os=beos",
+		"/ua?n=0",
+		"/ua?seed=x",
+		"/ua?as_of=yesterday",
+	} {
+		var body map[string]string
+		if status := get(path, &body); status != http.StatusBadRequest || body["error"] == "" {
+			t.Errorf("%s: status %d, %v", path, status, body)
+		}
+	}
+	var body map[string]string
+	if status := get("/nope", &body); status != http.StatusNotFound {
+		t.Errorf("/nope: status %d", status)
+	}
+}
+
+func TestHandlerConfig(t *testing.T) {
+	g, err := New(WithOS(OSMac), WithNavigator(NavigatorFirefox))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &Handler{Generator: g}
+	// Query parameters apply over the config of the generator
+	for path, want := range map[string]string{"/ua?n=3": "mac/firefox", "/ua?n=3&os=linux": "linux/firefox", "/ua?n=3&navigator=chrome&seed=1": "mac/chrome"} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		var uas []string
+		if err := json.Unmarshal(rec.Body.Bytes(), &uas); err != nil {
+			t.Fatalf("%s: %v: %s", path, err, rec.Body.String())
+		}
+		for _, ua := range uas {
+			if p, err := Parse(ua); err != nil || p.OS+"/"+p.Navigator != want {
+				t.Errorf("%s: %s, want %s", path, ua, want)
+			}
+		}
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	nav, err := renderNavigatorFor("desktop", "win", "chrome", "Windows NT 10.0", "93.0.4577.63")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := Headers(nav)
+	if h.Get("Sec-CH-UA-Platform") != `"Windows"` || h.Get("Sec-CH-UA-Mobile") != "?0" {
+		t.Errorf("chrome 93 client hints: %v", h)
+	}
+	if findings := Check(nav.UserAgent, h, &nav); len(findings) != 0 {
+		t.Errorf("%s: %v", nav.UserAgent, findings)
+	}
+	nav, err = renderNavigatorFor("desktop", "win", "chrome", "Windows NT 10.0", "86.0.4240.75")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := Headers(nav); h.Get("Sec-CH-UA") != "" {
+		t.Errorf("chrome 86 sends client hints: %v", h)
+	}
+}
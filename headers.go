@@ -0,0 +1,53 @@
+package useragent
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Request headers browsers send with a top-level navigation,
+// besides User-Agent and client hints
+var NAVIGATION_HEADERS = map[string]map[string]string{
+	"chrome": {
+		"Accept":          "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.9",
+		"Accept-Encoding": "gzip, deflate, br",
+		"Accept-Language": "en-US,en;q=0.9",
+	},
+	"firefox": {
+		"Accept":          "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+		"Accept-Encoding": "gzip, deflate, br",
+		"Accept-Language": "en-US,en;q=0.5",
+	},
+	"ie": {
+		"Accept":          "text/html, application/xhtml+xml, */*",
+		"Accept-Encoding": "gzip, deflate",
+		"Accept-Language": "en-US",
+	},
+}
+
+// Headers returns the request headers the browser of the navigator sends
+// with a page navigation: User-Agent, Accept headers of NAVIGATION_HEADERS
+// and, for Chrome 89 and later, the default User-Agent client hints.
+// Check reports no findings for the headers and the navigator.
+func Headers(nav Navigator) http.Header {
+	h := make(http.Header)
+	h.Set("User-Agent", nav.UserAgent)
+	for k, v := range NAVIGATION_HEADERS[nav.NavigatorID] {
+		h.Set(k, v)
+	}
+	if nav.NavigatorID == "chrome" {
+		if major := majorVersion(nav.BuildVersion); major >= 89 {
+			h.Set("Sec-CH-UA", fmt.Sprintf(`" Not A;Brand";v="99", "Chromium";v="%d", "Google Chrome";v="%d"`, major, major))
+			if nav.DeviceType == "smartphone" {
+				h.Set("Sec-CH-UA-Mobile", "?1")
+			} else {
+				h.Set("Sec-CH-UA-Mobile", "?0")
+			}
+			// sent by default since Chrome 93
+			if platform, ok := clientHintPlatform[nav.OSID]; ok && major >= 93 {
+				h.Set("Sec-CH-UA-Platform", `"`+platform+`"`)
+			}
+		}
+	}
+	return h
+}
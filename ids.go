@@ -0,0 +1,109 @@
+package useragent
+
+import (
+	"errors"
+	"strings"
+)
+
+// OSID is the id of an operating system, see OperatingSystem.ID.
+type OSID string
+
+// NavigatorID is the id of a browser, see Browser.ID.
+type NavigatorID string
+
+// DeviceType is a device type of operating systems and browsers.
+type DeviceType string
+
+// Ids of the built-in operating systems, browsers and device types.
+// The All ones choose every registered one in a config.
+const (
+	OSWindows OSID = "win"
+	OSMac     OSID = "mac"
+	OSLinux   OSID = "linux"
+	OSAndroid OSID = "android"
+	OSAll     OSID = "all"
+
+	NavigatorChrome  NavigatorID = "chrome"
+	NavigatorFirefox NavigatorID = "firefox"
+	NavigatorIE      NavigatorID = "ie"
+	NavigatorAll     NavigatorID = "all"
+
+	DeviceDesktop    DeviceType = "desktop"
+	DeviceSmartphone DeviceType = "smartphone"
+	DeviceTablet     DeviceType = "tablet"
+	DeviceAll        DeviceType = "all"
+)
+
+func (id OSID) String() string        { return string(id) }
+func (id NavigatorID) String() string { return string(id) }
+func (t DeviceType) String() string   { return string(t) }
+
+func (id OSID) MarshalText() ([]byte, error)        { return []byte(id), nil }
+func (id NavigatorID) MarshalText() ([]byte, error) { return []byte(id), nil }
+func (t DeviceType) MarshalText() ([]byte, error)   { return []byte(t), nil }
+
+// UnmarshalText sets the id from text, any id as components can be
+// registered; the config reports unknown ones.
+func (id *OSID) UnmarshalText(text []byte) error {
+	s, err := parseID("os", text)
+	*id = OSID(s)
+	return err
+}
+
+// UnmarshalText sets the id from text, see OSID.UnmarshalText.
+func (id *NavigatorID) UnmarshalText(text []byte) error {
+	s, err := parseID("navigator", text)
+	*id = NavigatorID(s)
+	return err
+}
+
+// UnmarshalText sets the device type from text, see OSID.UnmarshalText.
+func (t *DeviceType) UnmarshalText(text []byte) error {
+	s, err := parseID("device type", text)
+	*t = DeviceType(s)
+	return err
+}
+
+// Set implements flag.Value.
+func (id *OSID) Set(s string) error { return id.UnmarshalText([]byte(s)) }
+
+// Set implements flag.Value.
+func (id *NavigatorID) Set(s string) error { return id.UnmarshalText([]byte(s)) }
+
+// Set implements flag.Value.
+func (t *DeviceType) Set(s string) error { return t.UnmarshalText([]byte(s)) }
+
+func parseID(kind string, text []byte) (string, error) {
+	s := strings.TrimSpace(string(text))
+	if s == "" {
+		return "", errors.New("empty " + kind)
+	}
+	if strings.ContainsAny(s, ", \t") {
+		return "", errors.New("invalid " + kind + ": " + s)
+	}
+	return s, nil
+}
+
+// Ids as strings, for getOptionChoices
+func idStrings[T ~string](ids []T) []string {
+	if ids == nil {
+		return nil
+	}
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = string(id)
+	}
+	return s
+}
+
+// Value of an untyped option or of its typed version, only one of them
+// can be set
+func typedOption[T ~string](opt_name string, value any, typed []T) (any, error) {
+	if len(typed) == 0 {
+		return value, nil
+	}
+	if value != nil {
+		return nil, errors.New("Option " + opt_name + " is set twice, untyped and typed")
+	}
+	return idStrings(typed), nil
+}
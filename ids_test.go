@@ -0,0 +1,74 @@
+package useragent
+
+import (
+	"encoding"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"testing"
+)
+
+var (
+	_ fmt.Stringer             = OSWindows
+	_ encoding.TextMarshaler   = NavigatorChrome
+	_ encoding.TextUnmarshaler = new(DeviceType)
+	_ flag.Value               = new(OSID)
+	_ flag.Value               = new(NavigatorID)
+	_ flag.Value               = new(DeviceType)
+)
+
+func TestIDs(t *testing.T) {
+	b, err := json.Marshal(map[OSID][]NavigatorID{OSWindows: {NavigatorChrome, NavigatorIE}})
+	if err != nil || string(b) != `{"win":["chrome","ie"]}` {
+		t.Fatalf("marshal: %s %v", b, err)
+	}
+	var cfg struct {
+		OS      OSID         `json:"os"`
+		Devices []DeviceType `json:"devices"`
+	}
+	if err := json.Unmarshal([]byte(`{"os": "mac", "devices": ["tablet"]}`), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.OS != OSMac || len(cfg.Devices) != 1 || cfg.Devices[0] != DeviceTablet {
+		t.Errorf("unmarshal: %+v", cfg)
+	}
+	if err := json.Unmarshal([]byte(`{"os": ""}`), &cfg); err == nil {
+		t.Error("empty os: no error")
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var nav NavigatorID
+	fs.Var(&nav, "navigator", "browser id")
+	if err := fs.Parse([]string{"-navigator", "firefox"}); err != nil || nav != NavigatorFirefox {
+		t.Errorf("flag: %q %v", nav, err)
+	}
+	if err := nav.Set("chrome,ie"); err == nil {
+		t.Error("list as navigator id: no error")
+	}
+}
+
+func TestTypedConfig(t *testing.T) {
+	for _, cfg := range []UserAgentConfig{
+		{OSes: []OSID{OSAndroid}, Navigators: []NavigatorID{NavigatorFirefox}, DeviceTypes: []DeviceType{DeviceTablet}},
+		{OS: OSAndroid, Navigator: []NavigatorID{NavigatorFirefox}, DeviceType: []string{"tablet"}},
+		{OS: "android", Navigator: "firefox", DeviceType: []string{"tablet"}},
+	} {
+		variants, err := Variants(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(variants) != 1 || variants[0].OS != "android" || variants[0].Navigator != "firefox" || variants[0].DeviceType != "tablet" {
+			t.Errorf("%+v: variants %v", cfg, variants)
+		}
+	}
+	for _, cfg := range []UserAgentConfig{
+		{OS: "win", OSes: []OSID{OSMac}},
+		{Navigator: "chrome", Navigators: []NavigatorID{NavigatorChrome}},
+		{DeviceType: []string{"desktop"}, DeviceTypes: []DeviceType{DeviceDesktop}},
+		{OSes: []OSID{"beos"}},
+	} {
+		if _, err := Variants(cfg); err == nil {
+			t.Errorf("%+v: no error", cfg)
+		}
+	}
+}
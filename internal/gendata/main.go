@@ -0,0 +1,351 @@
+// Command gendata regenerates the version tables of the useragent package
+// from release histories, run with go generate in the package directory.
+//
+// It reads, from the -releases directory, a CSV file or a JSON array of
+// objects with the same columns for each of:
+//
+//	chrome   version,released             CHROME_BUILD, CHROME_RELEASE_DATE
+//	firefox  version,released             FIREFOX_VERSION
+//	android  version,released             ANDROID_PLATFORM
+//	macos    version,first_build,last_build  MACOSX_CHROME_BUILD_RANGE
+//
+// e.g. releases/chrome.csv or releases/chrome.json. Lines of CSV files
+// starting with # are comments. Versions are dotted numbers in increasing
+// order and released dates are YYYY-MM-DD, not decreasing with the
+// version; a chrome major is released on the earliest date of its builds.
+// The tables are written to -out.
+//
+// It also rebuilds smartphone_dev_id.json and tablet_dev_id.json of the
+// -data directory as the sorted device ids of smartphone_dev_ext.json and
+// tablet_dev_ext.json.
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func main() {
+	releases := flag.String("releases", "releases", "directory of the release history files")
+	data := flag.String("data", "data", "directory of the device files")
+	out := flag.String("out", "releases_gen.go", "generated Go file")
+	flag.Parse()
+	if err := run(*releases, *data, *out); err != nil {
+		fmt.Fprintln(os.Stderr, "gendata:", err)
+		os.Exit(1)
+	}
+}
+
+func run(releases, data, out string) error {
+	src, err := generateTables(releases)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(out, src, 0o644); err != nil {
+		return err
+	}
+	for _, dev := range []string{"smartphone", "tablet"} {
+		ids, err := deviceIDs(filepath.Join(data, dev+"_dev_ext.json"))
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(ids); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(data, dev+"_dev_id.json"), bytes.TrimSuffix(buf.Bytes(), []byte("\n")), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	reVersion = regexp.MustCompile(`^[0-9]+(\.[0-9]+)*$`)
+	reDate    = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}$`)
+)
+
+// A row of a release history, by column
+type row map[string]string
+
+// Rows of releases/name.csv or releases/name.json
+func readRows(dir, name string, columns ...string) ([]row, error) {
+	path := filepath.Join(dir, name+".csv")
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		path = filepath.Join(dir, name+".json")
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("no %s.csv or %s.json in %s", name, name, dir)
+		}
+		return jsonRows(path, data, columns)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comment = '#'
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(records) == 0 || strings.Join(records[0], ",") != strings.Join(columns, ",") {
+		return nil, fmt.Errorf("%s: header must be %s", path, strings.Join(columns, ","))
+	}
+	var rows []row
+	for _, record := range records[1:] {
+		rw := make(row)
+		for i, col := range columns {
+			rw[col] = strings.TrimSpace(record[i])
+		}
+		rows = append(rows, rw)
+	}
+	return rows, nil
+}
+
+func jsonRows(path string, data []byte, columns []string) ([]row, error) {
+	// numbers keep their text, 10.10 is not 10.1
+	var objects []map[string]any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&objects); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var rows []row
+	for i, obj := range objects {
+		if len(obj) != len(columns) {
+			return nil, fmt.Errorf("%s[%d]: fields must be %s", path, i, strings.Join(columns, ","))
+		}
+		rw := make(row)
+		for _, col := range columns {
+			v, ok := obj[col]
+			if !ok {
+				return nil, fmt.Errorf("%s[%d]: no %s", path, i, col)
+			}
+			switch v := v.(type) {
+			case string:
+				rw[col] = v
+			case json.Number:
+				rw[col] = v.String()
+			default:
+				return nil, fmt.Errorf("%s[%d]: %s must be a string or a number", path, i, col)
+			}
+		}
+		rows = append(rows, rw)
+	}
+	return rows, nil
+}
+
+// Versions and dates of a version,released history, checked for format
+// and ordering
+func readHistory(dir, name string) ([]string, []time.Time, error) {
+	rows, err := readRows(dir, name, "version", "released")
+	if err != nil {
+		return nil, nil, err
+	}
+	var versions []string
+	var dates []time.Time
+	for i, rw := range rows {
+		if err := checkVersion(versions, rw["version"]); err != nil {
+			return nil, nil, fmt.Errorf("%s line %d: %w", name, i+1, err)
+		}
+		if !reDate.MatchString(rw["released"]) {
+			return nil, nil, fmt.Errorf("%s line %d: released %q is not a YYYY-MM-DD date", name, i+1, rw["released"])
+		}
+		date, err := time.Parse("2006-01-02", rw["released"])
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s line %d: %w", name, i+1, err)
+		}
+		versions = append(versions, rw["version"])
+		dates = append(dates, date)
+	}
+	return versions, dates, nil
+}
+
+// Check version is well formed and greater than the last of versions
+func checkVersion(versions []string, version string) error {
+	if !reVersion.MatchString(version) {
+		return fmt.Errorf("invalid version %q", version)
+	}
+	if len(versions) != 0 && compareVersions(version, versions[len(versions)-1]) <= 0 {
+		return fmt.Errorf("version %s is not after %s", version, versions[len(versions)-1])
+	}
+	return nil
+}
+
+func compareVersions(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x, _ = strconv.Atoi(pa[i])
+		}
+		if i < len(pb) {
+			y, _ = strconv.Atoi(pb[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func major(version string) int {
+	m, _ := strconv.Atoi(strings.Split(version, ".")[0])
+	return m
+}
+
+// Check dates do not decrease
+func checkDates(name string, versions []string, dates []time.Time) error {
+	for i := 1; i < len(dates); i++ {
+		if dates[i].Before(dates[i-1]) {
+			return fmt.Errorf("%s: %s released %s, before %s released %s", name,
+				versions[i], dates[i].Format("2006-01-02"), versions[i-1], dates[i-1].Format("2006-01-02"))
+		}
+	}
+	return nil
+}
+
+func generateTables(dir string) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by internal/gendata from %s; DO NOT EDIT.\n\n", filepath.ToSlash(dir))
+	fmt.Fprintf(&b, "package useragent\n\nimport \"time\"\n\nvar (\n")
+
+	chrome, chrome_dates, err := readHistory(dir, "chrome")
+	if err != nil {
+		return nil, err
+	}
+	majors := make(map[int]time.Time)
+	var major_list []int
+	for i, version := range chrome {
+		m := major(version)
+		if date, ok := majors[m]; !ok || chrome_dates[i].Before(date) {
+			if !ok {
+				major_list = append(major_list, m)
+			}
+			majors[m] = chrome_dates[i]
+		}
+	}
+	var major_versions []string
+	var major_dates []time.Time
+	for _, m := range major_list {
+		major_versions = append(major_versions, strconv.Itoa(m))
+		major_dates = append(major_dates, majors[m])
+	}
+	if err := checkDates("chrome", major_versions, major_dates); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(&b, "\t// Chrome builds, releases/chrome\n\tCHROME_BUILD = []string{\n")
+	for _, version := range chrome {
+		fmt.Fprintf(&b, "\t\t%q,\n", version)
+	}
+	fmt.Fprintf(&b, "\t}\n\n\t// Release dates of the chrome majors listed in CHROME_BUILD\n\tCHROME_RELEASE_DATE = map[int]time.Time{\n")
+	for _, m := range major_list {
+		fmt.Fprintf(&b, "\t\t%d: %s,\n", m, goDate(majors[m]))
+	}
+	fmt.Fprintf(&b, "\t}\n\n")
+
+	firefox, firefox_dates, err := readHistory(dir, "firefox")
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDates("firefox", firefox, firefox_dates); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(&b, "\t// Firefox versions, releases/firefox\n\tFIREFOX_VERSION = []FirefoxVersion{\n")
+	for i, version := range firefox {
+		fmt.Fprintf(&b, "\t\t{%q, %s},\n", version, goDate(firefox_dates[i]))
+	}
+	fmt.Fprintf(&b, "\t}\n\n")
+
+	android, android_dates, err := readHistory(dir, "android")
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDates("android", android, android_dates); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(&b, "\t// Android platforms of OS_PLATFORM, releases/android\n\tANDROID_PLATFORM = []string{\n")
+	for i, version := range android {
+		fmt.Fprintf(&b, "\t\t// %s\n\t\t%q,\n", android_dates[i].Format("2006-01-02"), "Android "+version)
+	}
+	fmt.Fprintf(&b, "\t}\n\n")
+
+	rows, err := readRows(dir, "macos", "version", "first_build", "last_build")
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(&b, "\t// Minor builds of mac versions Chrome reports, [first, last+1),\n\t// releases/macos\n\tMACOSX_CHROME_BUILD_RANGE = map[string][]int{\n")
+	var mac []string
+	for i, rw := range rows {
+		if err := checkVersion(mac, rw["version"]); err != nil {
+			return nil, fmt.Errorf("macos line %d: %w", i+1, err)
+		}
+		mac = append(mac, rw["version"])
+		first, err1 := strconv.Atoi(rw["first_build"])
+		last, err2 := strconv.Atoi(rw["last_build"])
+		if err1 != nil || err2 != nil || first < 0 || last < first {
+			return nil, fmt.Errorf("macos line %d: invalid build range %s-%s", i+1, rw["first_build"], rw["last_build"])
+		}
+		fmt.Fprintf(&b, "\t\t%q: {%d, %d},\n", rw["version"], first, last+1)
+	}
+	fmt.Fprintf(&b, "\t}\n)\n")
+	return format.Source(b.Bytes())
+}
+
+func goDate(t time.Time) string {
+	return fmt.Sprintf("time.Date(%d, %d, %d, 0, 0, 0, 0, time.UTC)", t.Year(), t.Month(), t.Day())
+}
+
+// Sorted device ids of a device file, checked for empty
+// and duplicate ids
+func deviceIDs(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var devices []struct {
+		Name   string   `json:"name"`
+		DevIDs []string `json:"dev_ids"`
+	}
+	if err := json.Unmarshal(data, &devices); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	seen := make(map[string]string)
+	var ids []string
+	for _, device := range devices {
+		if len(device.DevIDs) == 0 {
+			return nil, fmt.Errorf("%s: device %q has no dev_ids", path, device.Name)
+		}
+		for _, id := range device.DevIDs {
+			if id == "" {
+				return nil, fmt.Errorf("%s: device %q has an empty dev id", path, device.Name)
+			}
+			if other, ok := seen[id]; ok {
+				return nil, fmt.Errorf("%s: dev id %q of %q and %q", path, id, other, device.Name)
+			}
+			seen[id] = device.Name
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The committed tables and device files are the ones go generate writes
+func TestGeneratedUpToDate(t *testing.T) {
+	src, err := generateTables("../../releases")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src = bytes.Replace(src, []byte("from ../../releases;"), []byte("from releases;"), 1)
+	committed, err := os.ReadFile("../../releases_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, committed) {
+		t.Error("releases_gen.go is out of date, run go generate")
+	}
+	for _, dev := range []string{"smartphone", "tablet"} {
+		ids, err := deviceIDs("../../data/" + dev + "_dev_ext.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile("../../data/" + dev + "_dev_id.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var committed []string
+		if err := json.Unmarshal(data, &committed); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(ids, "\n") != strings.Join(committed, "\n") {
+			t.Errorf("%s_dev_id.json is out of date, run go generate", dev)
+		}
+	}
+}
+
+func TestGenerateTablesErrors(t *testing.T) {
+	valid := map[string]string{
+		"chrome.csv":  "version,released\n86.0.4240.75,2020-10-06\n",
+		"firefox.csv": "version,released\n50.0,2016-11-15\n",
+		"android.csv": "version,released\n7.0,2016-08-22\n",
+		"macos.csv":   "version,first_build,last_build\n10.12,0,1\n",
+	}
+	for _, tt := range []struct {
+		file, data, want string
+	}{
+		{"chrome.csv", "version,date\n", "header must be"},
+		{"chrome.csv", "version,released\n86.0.4240.75,2020-10-06\n86.0.4240.7,2020-10-06\n", "is not after"},
+		{"chrome.csv", "version,released\n85.0.4183.83,2020-10-06\n86.0.4240.75,2020-08-25\n", "before 85"},
+		{"firefox.csv", "version,released\nv50.0,2016-11-15\n", "invalid version"},
+		{"firefox.csv", "version,released\n50.0,15/11/2016\n", "YYYY-MM-DD"},
+		{"android.csv", "version,released\n7.0,2016-08-22\n7.1,2016-01-01\n", "before 7.0"},
+		{"macos.csv", "version,first_build,last_build\n10.12,2,1\n", "invalid build range"},
+	} {
+		dir := t.TempDir()
+		for name, data := range valid {
+			if name == tt.file {
+				data = tt.data
+			}
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		_, err := generateTables(dir)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s %q: error %v, want %q", tt.file, tt.data, err, tt.want)
+		}
+	}
+
+	// JSON release history instead of CSV
+	dir := t.TempDir()
+	for name, data := range valid {
+		if name == "firefox.csv" {
+			name, data = "firefox.json", `[{"version": "50.0", "released": "2016-11-15"}]`
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	src, err := generateTables(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(src, []byte(`{"50.0", time.Date(2016, 11, 15, 0, 0, 0, 0, time.UTC)}`)) {
+		t.Errorf("firefox.json not generated:\n%s", src)
+	}
+
+	// JSON numbers keep their text
+	os.Remove(filepath.Join(dir, "macos.csv"))
+	macos := filepath.Join(dir, "macos.json")
+	if err := os.WriteFile(macos, []byte(`[{"version": 10.10, "first_build": 0, "last_build": 5}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src, err = generateTables(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(src, []byte(`"10.10"`)) {
+		t.Errorf("macos.json version 10.10 not kept:\n%s", src)
+	}
+	if err := os.WriteFile(macos, []byte(`[{"version": true, "first_build": 0, "last_build": 5}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := generateTables(dir); err == nil || !strings.Contains(err.Error(), "string or a number") {
+		t.Errorf("boolean version: %v", err)
+	}
+}
+
+func TestDeviceIDsDuplicate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dev_ext.json")
+	data := `[{"name": "a", "dev_ids": ["X Build/1"]}, {"name": "b", "dev_ids": ["X Build/1"]}]`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := deviceIDs(path); err == nil {
+		t.Error("duplicate dev id: no error")
+	}
+}
@@ -0,0 +1,330 @@
+// Package lint validates the data the useragent package generates from:
+// the device files of its data directory and its version tables.
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mwaurawakati/useragent"
+)
+
+// Problem is a violation found in the data.
+type Problem struct {
+	// File is the data file or the table, e.g. "tablet_dev_ext.json"
+	// or "CHROME_BUILD"
+	File string
+	// Rule is the id of the violated rule, e.g. "duplicate-dev-id"
+	Rule string
+	// Message describes the violation
+	Message string
+}
+
+func (p Problem) String() string {
+	return p.File + ": " + p.Rule + ": " + p.Message
+}
+
+// Year of the first release of Android versions, by major.minor
+// or major since Android 9
+var ANDROID_RELEASE_YEAR = map[string]int{
+	"4.0": 2011,
+	"4.1": 2012,
+	"4.2": 2012,
+	"4.3": 2013,
+	"4.4": 2013,
+	"5.0": 2014,
+	"5.1": 2015,
+	"6.0": 2015,
+	"7.0": 2016,
+	"7.1": 2016,
+	"8.0": 2017,
+	"8.1": 2017,
+	"9":   2018,
+	"10":  2019,
+	"11":  2020,
+	"12":  2021,
+	"13":  2022,
+	"14":  2023,
+}
+
+// Fields of device entries of the *_dev_ext.json files
+var deviceFields = []string{"android_versions", "cpu", "dev_ids", "name", "released", "resolution"}
+
+type device struct {
+	AndroidVersions []string `json:"android_versions"`
+	CPU             string   `json:"cpu"`
+	DevIDs          []string `json:"dev_ids"`
+	Name            string   `json:"name"`
+	Released        int      `json:"released"`
+	Resolution      []int    `json:"resolution"`
+}
+
+var (
+	reVersion = regexp.MustCompile(`^[0-9]+(\.[0-9]+)*$`)
+	reDevID   = regexp.MustCompile(`^\S.* Build/\S+$`)
+)
+
+// Data lints the device files of fsys, laid out like the data directory
+// of the useragent package, and the version tables of the package.
+func Data(fsys fs.FS) []Problem {
+	return append(Devices(fsys), Tables()...)
+}
+
+// Devices lints smartphone_dev_ext.json and tablet_dev_ext.json of fsys
+// and the *_dev_id.json files built from them:
+//   - schema: unknown or missing fields, e.g. a misspelled field name
+//   - android-version: versions not like "4.4.2", of unknown Android
+//     releases, or not in increasing order
+//   - release-year: device released before its Android version
+//   - duplicate-device: device listed twice, or as smartphone and tablet
+//   - duplicate-dev-id, dev-id-format: dev ids listed twice, or not like
+//     "D5503 Build/14.6.A.1.236"
+//   - dev-id-list: *_dev_id.json differs from the ids of *_dev_ext.json
+func Devices(fsys fs.FS) []Problem {
+	var problems []Problem
+	report := func(file, rule, format string, args ...any) {
+		problems = append(problems, Problem{file, rule, fmt.Sprintf(format, args...)})
+	}
+	// file of device names and of dev ids
+	names := make(map[string]string)
+	dev_ids := make(map[string]string)
+	for _, dev := range []string{"smartphone", "tablet"} {
+		file := dev + "_dev_ext.json"
+		devices, ok := readDevices(fsys, file, report)
+		if !ok {
+			continue
+		}
+		var ids []string
+		for i, d := range devices {
+			where := fmt.Sprintf("device %d %q", i, d.Name)
+			if other, ok := names[d.Name]; ok {
+				report(file, "duplicate-device", "%s also in %s", where, other)
+			}
+			names[d.Name] = file
+			for _, id := range d.DevIDs {
+				if !reDevID.MatchString(id) {
+					report(file, "dev-id-format", "%s: dev id %q", where, id)
+				}
+				if other, ok := dev_ids[id]; ok {
+					report(file, "duplicate-dev-id", "%s: dev id %q also in %s", where, id, other)
+				}
+				dev_ids[id] = file
+				ids = append(ids, id)
+			}
+			lintAndroidVersions(file, where, d, report)
+		}
+		sort.Strings(ids)
+		lintDevIDList(fsys, dev+"_dev_id.json", ids, report)
+	}
+	return problems
+}
+
+// Decode a device file, reporting schema violations of every entry
+func readDevices(fsys fs.FS, file string, report func(file, rule, format string, args ...any)) ([]device, bool) {
+	data, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		report(file, "schema", "%v", err)
+		return nil, false
+	}
+	var entries []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		report(file, "schema", "%v", err)
+		return nil, false
+	}
+	devices := make([]device, len(entries))
+	for i, entry := range entries {
+		for field := range entry {
+			if !contains(deviceFields, field) {
+				report(file, "schema", "device %d: unknown field %q", i, field)
+			}
+		}
+		for _, field := range deviceFields {
+			if _, ok := entry[field]; !ok {
+				report(file, "schema", "device %d: missing field %q", i, field)
+			}
+		}
+		raw, _ := json.Marshal(entry)
+		if err := json.Unmarshal(raw, &devices[i]); err != nil {
+			report(file, "schema", "device %d: %v", i, err)
+		}
+		if devices[i].Name == "" || len(devices[i].DevIDs) == 0 {
+			report(file, "schema", "device %d: no name or dev_ids", i)
+		}
+	}
+	return devices, true
+}
+
+func lintAndroidVersions(file, where string, d device, report func(file, rule, format string, args ...any)) {
+	if len(d.AndroidVersions) == 0 {
+		return
+	}
+	for i, v := range d.AndroidVersions {
+		if !reVersion.MatchString(v) {
+			report(file, "android-version", "%s: version %q is not like \"4.4.2\"", where, v)
+			return
+		}
+		if _, ok := androidReleaseYear(v); !ok {
+			report(file, "android-version", "%s: unknown Android version %s", where, v)
+			return
+		}
+		if i > 0 && compareVersions(v, d.AndroidVersions[i-1]) < 0 {
+			report(file, "android-version", "%s: version %s after %s", where, v, d.AndroidVersions[i-1])
+		}
+	}
+	year, _ := androidReleaseYear(d.AndroidVersions[0])
+	if d.Released < year {
+		report(file, "release-year", "%s: released %d with Android %s of %d", where, d.Released, d.AndroidVersions[0], year)
+	}
+}
+
+// Compare the dev id list with the ids of the device file
+func lintDevIDList(fsys fs.FS, file string, ids []string, report func(file, rule, format string, args ...any)) {
+	data, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		report(file, "dev-id-list", "%v", err)
+		return
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		report(file, "dev-id-list", "%v", err)
+		return
+	}
+	if strings.Join(list, "\n") != strings.Join(ids, "\n") {
+		report(file, "dev-id-list", "differs from the sorted dev ids of the device file, run go generate")
+	}
+}
+
+// Tables lints the version tables of the useragent package:
+//   - version-format: versions not like "86.0.4240.75" or "MSIE 11.0"
+//   - duplicate-version: versions listed twice
+//   - release-date: builds without release date
+//   - platform: OS_PLATFORM entries of unknown format or Android version,
+//     mac versions without MACOSX_CHROME_BUILD_RANGE, SUPPORT_MATRIX
+//     entries not matching any platform
+func Tables() []Problem {
+	var problems []Problem
+	report := func(file, rule, format string, args ...any) {
+		problems = append(problems, Problem{file, rule, fmt.Sprintf(format, args...)})
+	}
+
+	seen := make(map[string]bool)
+	for _, build := range useragent.CHROME_BUILD {
+		if !reVersion.MatchString(build) || strings.Count(build, ".") != 3 {
+			report("CHROME_BUILD", "version-format", "%q", build)
+			continue
+		}
+		if seen[build] {
+			report("CHROME_BUILD", "duplicate-version", "%s", build)
+		}
+		seen[build] = true
+		major, _ := strconv.Atoi(strings.Split(build, ".")[0])
+		if useragent.CHROME_RELEASE_DATE[major].IsZero() {
+			report("CHROME_RELEASE_DATE", "release-date", "no date of chrome %d", major)
+		}
+	}
+	for _, fxvs := range useragent.FIREFOX_VERSION {
+		if !reVersion.MatchString(fxvs.Version) {
+			report("FIREFOX_VERSION", "version-format", "%q", fxvs.Version)
+		}
+		if seen["firefox "+fxvs.Version] {
+			report("FIREFOX_VERSION", "duplicate-version", "%s", fxvs.Version)
+		}
+		seen["firefox "+fxvs.Version] = true
+		if fxvs.Date.IsZero() {
+			report("FIREFOX_VERSION", "release-date", "no date of firefox %s", fxvs.Version)
+		}
+	}
+	for _, iebuild := range useragent.IE_VERSION {
+		if iebuild.StringVersion != fmt.Sprintf("MSIE %d.0", iebuild.NumericVersion) || !reVersion.MatchString(iebuild.TridentVersion) {
+			report("IE_VERSION", "version-format", "%d %q trident %q", iebuild.NumericVersion, iebuild.StringVersion, iebuild.TridentVersion)
+		}
+		if iebuild.Date.IsZero() {
+			report("IE_VERSION", "release-date", "no date of %s", iebuild.StringVersion)
+		}
+	}
+
+	var platforms []string
+	for os_id, list := range useragent.OS_PLATFORM {
+		platforms = append(platforms, list...)
+		for _, platform := range list {
+			switch os_id {
+			case "android":
+				v := strings.TrimPrefix(platform, "Android ")
+				if _, ok := androidReleaseYear(v); v == platform || !reVersion.MatchString(v) || !ok {
+					report("OS_PLATFORM", "platform", "android platform %q", platform)
+				}
+			case "mac":
+				v := strings.TrimPrefix(platform, "Macintosh; Intel Mac OS X ")
+				if _, ok := useragent.MACOSX_CHROME_BUILD_RANGE[v]; !ok {
+					report("MACOSX_CHROME_BUILD_RANGE", "platform", "no build range of %q", platform)
+				}
+			}
+		}
+	}
+	for v, r := range useragent.MACOSX_CHROME_BUILD_RANGE {
+		if len(r) != 2 || r[0] < 0 || r[1] <= r[0] {
+			report("MACOSX_CHROME_BUILD_RANGE", "platform", "invalid range %v of %s", r, v)
+		}
+	}
+	for nav_id, support := range useragent.SUPPORT_MATRIX {
+		for platform := range support {
+			found := false
+			for _, p := range platforms {
+				found = found || p == platform || strings.HasPrefix(p, platform+".")
+			}
+			if !found {
+				report("SUPPORT_MATRIX", "platform", "%s platform %q matches no OS_PLATFORM entry", nav_id, platform)
+			}
+		}
+	}
+	sort.Slice(problems, func(i, j int) bool { return problems[i].String() < problems[j].String() })
+	return problems
+}
+
+// Release year of an Android version
+func androidReleaseYear(version string) (int, bool) {
+	parts := strings.Split(version, ".")
+	key := parts[0]
+	if major, _ := strconv.Atoi(parts[0]); major < 9 {
+		if len(parts) < 2 {
+			return 0, false
+		}
+		key += "." + parts[1]
+	}
+	year, ok := ANDROID_RELEASE_YEAR[key]
+	return year, ok
+}
+
+func compareVersions(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x, _ = strconv.Atoi(pa[i])
+		}
+		if i < len(pb) {
+			y, _ = strconv.Atoi(pb[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func contains(s []string, str string) bool {
+	for _, v := range s {
+		if v == str {
+			return true
+		}
+	}
+	return false
+}
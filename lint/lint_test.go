@@ -0,0 +1,53 @@
+package lint
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// The data of the repository is clean, CI fails otherwise
+func TestData(t *testing.T) {
+	for _, p := range Data(os.DirFS("../data")) {
+		t.Error(p)
+	}
+}
+
+func TestDevices(t *testing.T) {
+	fsys := fstest.MapFS{
+		"smartphone_dev_ext.json": {Data: []byte(`[
+  {"android_versionss": ["v4.4.2"], "cpu": "", "dev_ids": ["A Build/1"], "name": "A", "released": 2014, "resolution": [720, 1280]},
+  {"android_versions": ["5.0", "4.4"], "cpu": "", "dev_ids": ["B Build/1"], "name": "B", "released": 2014, "resolution": [720, 1280]},
+  {"android_versions": ["6.0"], "cpu": "", "dev_ids": ["C Build/1", "C"], "name": "C", "released": 2014, "resolution": [720, 1280]},
+  {"android_versions": ["3.2"], "cpu": "", "dev_ids": ["D Build/1"], "name": "D", "released": 2014, "resolution": [720, 1280]}
+]`)},
+		"smartphone_dev_id.json": {Data: []byte(`["A Build/1", "B Build/1", "C", "C Build/1", "D Build/1"]`)},
+		"tablet_dev_ext.json": {Data: []byte(`[
+  {"android_versions": ["4.4"], "cpu": "", "dev_ids": ["B Build/1"], "name": "B", "released": 2014, "resolution": [800, 1280]}
+]`)},
+		"tablet_dev_id.json": {Data: []byte(`[]`)},
+	}
+	var got []string
+	for _, p := range Devices(fsys) {
+		got = append(got, p.String())
+	}
+	for _, want := range []string{
+		`smartphone_dev_ext.json: schema: device 0: unknown field "android_versionss"`,
+		`smartphone_dev_ext.json: schema: device 0: missing field "android_versions"`,
+		`smartphone_dev_ext.json: android-version: device 1 "B": version 4.4 after 5.0`,
+		`smartphone_dev_ext.json: release-year: device 2 "C": released 2014 with Android 6.0 of 2015`,
+		`smartphone_dev_ext.json: dev-id-format: device 2 "C": dev id "C"`,
+		`smartphone_dev_ext.json: android-version: device 3 "D": unknown Android version 3.2`,
+		`tablet_dev_ext.json: duplicate-device: device 0 "B" also in smartphone_dev_ext.json`,
+		`tablet_dev_ext.json: duplicate-dev-id: device 0 "B": dev id "B Build/1" also in smartphone_dev_ext.json`,
+		`tablet_dev_id.json: dev-id-list: differs from the sorted dev ids of the device file, run go generate`,
+	} {
+		if !contains(got, want) {
+			t.Errorf("no problem %s in:\n%s", want, strings.Join(got, "\n"))
+		}
+	}
+	if len(got) != 9 {
+		t.Errorf("%d problems, want 9:\n%s", len(got), strings.Join(got, "\n"))
+	}
+}
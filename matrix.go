@@ -0,0 +1,149 @@
+package useragent
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Matrix returns a covering set of the navigators the package level
+// functions can generate for the config, see Generator.Matrix.
+func Matrix(cfg UserAgentConfig, strength int) ([]Navigator, error) {
+	return defaultGenerator.Matrix(cfg, strength)
+}
+
+// Matrix returns a small set of navigators covering the factors variant
+// (device type, os and browser), platform version and browser major of
+// the config. With strength 1 every value of each factor is in the set at
+// least once, with strength 2 every pair of values that can occur together,
+// with strength 3 every combination. The set is built greedily, picking
+// the combination covering most of what is left, and is the same for the
+// same config and registry. Navigators use the newest build of the major
+// and the first system variant of the platform, or the first ones the
+// config does not deny; combinations only denied user agents have are
+// left out.
+func (g *Generator) Matrix(cfg UserAgentConfig, strength int) ([]Navigator, error) {
+	if strength < 1 || strength > 3 {
+		return nil, fmt.Errorf("strength must be 1, 2 or 3, got %d", strength)
+	}
+	s, err := g.reg().space(&cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Candidates are the (variant, platform, major) combinations
+	type candidate struct {
+		seg *segment
+		// builds of the major, newest first
+		builds []Build
+		items  []string
+	}
+	var candidates []candidate
+	uncovered := make(map[string]bool)
+	for i := range s.segments {
+		seg := &s.segments[i]
+		v := seg.variant
+		builds := make(map[int][]Build)
+		var majors []int
+		for _, build := range seg.builds {
+			major := majorVersion(build.Version)
+			if _, ok := builds[major]; !ok {
+				majors = append(majors, major)
+			}
+			builds[major] = append(builds[major], build)
+		}
+		for _, major := range majors {
+			factors := []string{
+				"variant=" + v.DeviceType + "/" + v.OS + "/" + v.Navigator,
+				"platform=" + seg.platform,
+				"major=" + v.Navigator + " " + strconv.Itoa(major),
+			}
+			major_builds := builds[major]
+			sort.SliceStable(major_builds, func(i, j int) bool {
+				return compareVersions(major_builds[i].Version, major_builds[j].Version) > 0
+			})
+			c := candidate{seg: seg, builds: major_builds, items: coverItems(factors, strength)}
+			for _, item := range c.items {
+				uncovered[item] = true
+			}
+			candidates = append(candidates, c)
+		}
+	}
+
+	var navs []Navigator
+	for len(uncovered) != 0 {
+		best, best_count := -1, 0
+		for i, c := range candidates {
+			count := 0
+			for _, item := range c.items {
+				if uncovered[item] {
+					count++
+				}
+			}
+			if count > best_count {
+				best, best_count = i, count
+			}
+		}
+		if best < 0 {
+			// left items are only in denied candidates
+			break
+		}
+		c := &candidates[best]
+		nav, ok, err := g.matrixNavigator(c.seg, c.builds, s.deny)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			c.items = nil
+			continue
+		}
+		for _, item := range c.items {
+			delete(uncovered, item)
+		}
+		navs = append(navs, nav)
+	}
+	return navs, nil
+}
+
+// First navigator of the builds and systems of the segment the deny list
+// does not deny, in order of builds then systems
+func (g *Generator) matrixNavigator(seg *segment, builds []Build, deny *denyList) (Navigator, bool, error) {
+	v := seg.variant
+	for _, build := range builds {
+		app, err := seg.browser.App(v.OS, build)
+		if err != nil {
+			return Navigator{}, false, err
+		}
+		for i := 0; i < seg.systems.n; i++ {
+			system, err := seg.os.System(v.DeviceType, seg.platform, v.Navigator, seg.systems.at(i))
+			if err != nil {
+				return Navigator{}, false, err
+			}
+			nav, err := g.renderNavigator(v.DeviceType, v.OS, seg.browser, system, app)
+			if err != nil {
+				return Navigator{}, false, err
+			}
+			if !deny.denies(nav.UserAgent) {
+				return nav, true, nil
+			}
+		}
+	}
+	return Navigator{}, false, nil
+}
+
+// Combinations of strength factor values to cover
+func coverItems(factors []string, strength int) []string {
+	switch strength {
+	case 1:
+		return factors
+	case 2:
+		var items []string
+		for i := range factors {
+			for j := i + 1; j < len(factors); j++ {
+				items = append(items, factors[i]+"|"+factors[j])
+			}
+		}
+		return items
+	}
+	return []string{factors[0] + "|" + factors[1] + "|" + factors[2]}
+}
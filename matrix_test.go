@@ -0,0 +1,66 @@
+package useragent
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestMatrix(t *testing.T) {
+	cfg := UserAgentConfig{OS: "all"}
+	variants, err := Variants(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, _ := All(cfg)
+	majors := make(map[string]bool)
+	for nav := range all {
+		majors[nav.NavigatorID+strconv.Itoa(majorVersion(nav.BuildVersion))] = true
+	}
+
+	prev := 0
+	for strength := 1; strength <= 3; strength++ {
+		navs, err := Matrix(cfg, strength)
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, _ := Matrix(cfg, strength)
+		if !reflect.DeepEqual(navs, again) {
+			t.Errorf("strength %d: matrix is not deterministic", strength)
+		}
+		if len(navs) < prev {
+			t.Errorf("strength %d: %d navigators, less than strength %d", strength, len(navs), strength-1)
+		}
+		prev = len(navs)
+
+		covered := make(map[string]bool)
+		for _, nav := range navs {
+			covered[nav.DeviceType+nav.OSID+nav.NavigatorID] = true
+			covered[nav.PlatformVersion] = true
+			covered[nav.NavigatorID+strconv.Itoa(majorVersion(nav.BuildVersion))] = true
+			if findings := Check(nav.UserAgent, nil, &nav); len(findings) != 0 {
+				t.Errorf("%s: %v", nav.UserAgent, findings)
+			}
+		}
+		for _, v := range variants {
+			if !covered[v.DeviceType+v.OS+v.Navigator] {
+				t.Errorf("strength %d: variant %v not covered", strength, v)
+			}
+			for _, platform := range v.Platforms {
+				if !covered[platform] {
+					t.Errorf("strength %d: platform %s not covered", strength, platform)
+				}
+			}
+		}
+		for major := range majors {
+			if !covered[major] {
+				t.Errorf("strength %d: %s not covered", strength, major)
+			}
+		}
+		t.Logf("strength %d: %d navigators", strength, len(navs))
+	}
+
+	if _, err := Matrix(cfg, 4); err == nil {
+		t.Error("strength 4: no error")
+	}
+}
@@ -0,0 +1,62 @@
+package useragent
+
+import "time"
+
+// Navigator is a generated browser identity: the User-Agent header and the
+// matching fields of the JavaScript navigator object. It carries the ids and
+// system components it was built from, so it can be persisted (e.g. as JSON)
+// and regenerated later, see Navigator.Age.
+type Navigator struct {
+	DeviceType  string `json:"device_type"`
+	OSID        string `json:"os_id"`
+	NavigatorID string `json:"navigator_id"`
+
+	// PlatformVersion is the OS_PLATFORM entry, e.g. "Windows NT 10.0"
+	PlatformVersion string `json:"platform_version"`
+	// UAPlatform is the platform as it appears inside the User-Agent
+	UAPlatform string `json:"ua_platform"`
+	// CPU is the OS_CPU entry, DeviceID the device id of mobile systems
+	CPU      string `json:"cpu"`
+	DeviceID string `json:"device_id"`
+
+	Platform     string `json:"platform"`
+	Oscpu        string `json:"oscpu"`
+	BuildVersion string `json:"build_version"`
+	BuildID      string `json:"build_id"`
+	AppVersion   string `json:"app_version"`
+	AppName      string `json:"app_name"`
+	AppCodeName  string `json:"app_code_name"`
+	Product      string `json:"product"`
+	ProductSub   string `json:"product_sub"`
+	Vendor       string `json:"vendor"`
+	VendorSub    string `json:"vendor_sub"`
+	UserAgent    string `json:"user_agent"`
+}
+
+// Generate web navigator's config.
+// Returns the navigator of a random browser limited by the config
+func GenerateNavigator(uaconfig ...UserAgentConfig) Navigator {
+	return defaultGenerator.GenerateNavigator(uaconfig...)
+}
+
+// Age returns the navigator as it would look at the given date, after the
+// browser auto-updated itself to the newest build released by then.
+// OS, device and platform are kept and the browser is only upgraded within
+// the versions the platform can run. User-Agent and the navigator fields
+// depending on the build are regenerated. A navigator that has no newer
+// build available is returned unchanged.
+func (n Navigator) Age(at time.Time) (Navigator, error) {
+	return defaultGenerator.Age(n, at)
+}
+
+// System components the navigator was built from
+func (n Navigator) system() System {
+	return System{
+		PlatformVersion: n.PlatformVersion,
+		Platform:        n.Platform,
+		UAPlatform:      n.UAPlatform,
+		Oscpu:           n.Oscpu,
+		CPU:             n.CPU,
+		DeviceID:        n.DeviceID,
+	}
+}
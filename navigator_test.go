@@ -0,0 +1,70 @@
+package useragent
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNavigatorAge(t *testing.T) {
+	nav, err := renderNavigatorFor("desktop", "win", "chrome", "Windows NT 10.0", "80.0.3987.99")
+	if err != nil {
+		t.Fatal(err)
+	}
+	aged, err := nav.Age(time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aged.BuildVersion != "85.0.4183.127" {
+		t.Errorf("build version: got %s, want 85.0.4183.127", aged.BuildVersion)
+	}
+	if !strings.Contains(aged.UserAgent, "Chrome/85.0.4183.127") {
+		t.Errorf("user agent not regenerated: %s", aged.UserAgent)
+	}
+	if aged.PlatformVersion != nav.PlatformVersion || aged.UAPlatform != nav.UAPlatform || aged.Oscpu != nav.Oscpu {
+		t.Errorf("system components changed: %+v", aged)
+	}
+
+	// aging to an earlier date never downgrades
+	same, err := aged.Age(time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if same != aged {
+		t.Errorf("navigator downgraded: %s", same.BuildVersion)
+	}
+}
+
+func TestNavigatorAgeIELimit(t *testing.T) {
+	nav, err := renderNavigatorFor("desktop", "win", "ie", "Windows NT 6.2", "MSIE 9.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	aged, err := nav.Age(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aged.BuildVersion != "MSIE 10.0" {
+		t.Errorf("build version: got %s, want MSIE 10.0", aged.BuildVersion)
+	}
+	if aged.AppName != "Microsoft Internet Explorer" {
+		t.Errorf("app name: got %s", aged.AppName)
+	}
+}
+
+func renderNavigatorFor(device_type, os_id, navigator_id, platform, build string) (Navigator, error) {
+	reg := defaultRegistry()
+	os, b, err := reg.components(os_id, navigator_id)
+	if err != nil {
+		return Navigator{}, err
+	}
+	system, err := os.System(device_type, platform, navigator_id, 0)
+	if err != nil {
+		return Navigator{}, err
+	}
+	app, err := b.App(os_id, Build{Version: build})
+	if err != nil {
+		return Navigator{}, err
+	}
+	return defaultGenerator.renderNavigator(device_type, os_id, b, system, app)
+}
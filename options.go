@@ -0,0 +1,143 @@
+package useragent
+
+import "time"
+
+// Option configures a generator made with New.
+type Option func(*Generator) error
+
+// New returns a generator configured with the options, applied in order.
+// The config the options build is the one GenerateNavigator and
+// GenerateUserAgent use when called without config, see Generator.Config.
+// An error is returned if an option fails or the config matches nothing.
+func New(opts ...Option) (*Generator, error) {
+	g := new(Generator)
+	for _, opt := range opts {
+		if err := opt(g); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := g.reg().variants(&g.config); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// WithConfig sets the config of the generator, replacing the one of the
+// previous options.
+func WithConfig(cfg UserAgentConfig) Option {
+	return func(g *Generator) error {
+		g.config = cfg
+		return nil
+	}
+}
+
+// WithOS limits the config to the operating systems.
+func WithOS(ids ...OSID) Option {
+	return func(g *Generator) error {
+		g.config.OS, g.config.OSes = nil, ids
+		return nil
+	}
+}
+
+// WithNavigator limits the config to the browsers.
+func WithNavigator(ids ...NavigatorID) Option {
+	return func(g *Generator) error {
+		g.config.Navigator, g.config.Navigators = nil, ids
+		return nil
+	}
+}
+
+// WithDeviceType limits the config to the device types.
+func WithDeviceType(types ...DeviceType) Option {
+	return func(g *Generator) error {
+		g.config.DeviceType, g.config.DeviceTypes = nil, types
+		return nil
+	}
+}
+
+// WithPlatform limits the config to the platform versions,
+// e.g. "Windows NT 10.0".
+func WithPlatform(versions ...string) Option {
+	return func(g *Generator) error {
+		g.config.Platform = versions
+		return nil
+	}
+}
+
+// WithAsOf limits the config to builds released by the date.
+func WithAsOf(t time.Time) Option {
+	return func(g *Generator) error {
+		g.config.AsOf = t
+		return nil
+	}
+}
+
+// WithVersions limits builds by navigator id, e.g.
+// {"chrome": {Min: "84"}}, see UserAgentConfig.Versions.
+func WithVersions(versions map[string]VersionRange) Option {
+	return func(g *Generator) error {
+		g.config.Versions = versions
+		return nil
+	}
+}
+
+// WithPlatformVersions limits platform versions by os id,
+// see UserAgentConfig.PlatformVersions.
+func WithPlatformVersions(versions map[string]VersionRange) Option {
+	return func(g *Generator) error {
+		g.config.PlatformVersions = versions
+		return nil
+	}
+}
+
+// WithExclude sets what the config leaves out.
+func WithExclude(ex Exclude) Option {
+	return func(g *Generator) error {
+		g.config.Exclude = ex
+		return nil
+	}
+}
+
+// WithWeights makes the generator sample with the weights, see SetWeights.
+func WithWeights(w *Weights) Option {
+	return func(g *Generator) error {
+		if w != nil {
+			if err := w.validate(); err != nil {
+				return err
+			}
+		}
+		g.SetWeights(w)
+		return nil
+	}
+}
+
+// WithSeed makes the generator deterministic, see Seed.
+func WithSeed(seed int64) Option {
+	return func(g *Generator) error {
+		g.Seed(seed)
+		return nil
+	}
+}
+
+// WithRegistry makes the generator use the registry, see SetRegistry.
+func WithRegistry(r *Registry) Option {
+	return func(g *Generator) error {
+		g.SetRegistry(r)
+		return nil
+	}
+}
+
+// WithDataPack makes the generator use the data pack, see UseDataPack.
+func WithDataPack(p *DataPack) Option {
+	return func(g *Generator) error {
+		g.UseDataPack(p)
+		return nil
+	}
+}
+
+// Config returns the config of the options of New, the zero config for
+// generators not made with New. Methods taking a config, like GenerateN,
+// do not use it, pass it to them.
+func (g *Generator) Config() UserAgentConfig {
+	return g.config
+}
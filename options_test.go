@@ -0,0 +1,60 @@
+package useragent
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	asOf := time.Date(2016, 9, 1, 0, 0, 0, 0, time.UTC)
+	g, err := New(
+		WithOS(OSWindows, OSMac),
+		WithNavigator(NavigatorFirefox),
+		WithAsOf(asOf),
+		WithWeights(&Weights{Variants: map[string]float64{"desktop/mac/firefox": 1}}),
+		WithSeed(3),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		nav := g.GenerateNavigator()
+		if nav.OSID != "mac" || nav.NavigatorID != "firefox" {
+			t.Fatalf("generated %s", nav.UserAgent)
+		}
+		if v := Version(nav.BuildVersion); v.Major() > 48 {
+			t.Fatalf("firefox %s not released by %s", v, asOf.Format("2006-01-02"))
+		}
+	}
+	// An explicit config replaces the one of the options
+	if nav := g.GenerateNavigator(UserAgentConfig{OS: "linux"}); nav.OSID != "linux" {
+		t.Errorf("generated %s", nav.UserAgent)
+	}
+
+	var navs [2][]Navigator
+	for i := range navs {
+		g, err := New(WithOS(OSWindows, OSMac), WithNavigator(NavigatorFirefox), WithSeed(3))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if navs[i], err = g.GenerateN(g.Config(), 5); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := range navs[0] {
+		if navs[0][i] != navs[1][i] {
+			t.Fatal("same options, different navigators")
+		}
+	}
+
+	for _, opts := range [][]Option{
+		{WithOS("beos")},
+		{WithOS(OSWindows), WithDeviceType(DeviceTablet)},
+		{WithConfig(UserAgentConfig{OS: "win"}), WithNavigator(NavigatorIE), WithAsOf(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))},
+		{WithWeights(&Weights{Majors: map[string]float64{"chrome 86": -1}})},
+	} {
+		if _, err := New(opts...); err == nil {
+			t.Errorf("New(%d options): no error", len(opts))
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package useragent
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrUnrecognizedUA is returned by Parse for user agents that do not match
+// any of the browsers known to the generator.
+var ErrUnrecognizedUA = errors.New("unrecognized user agent")
+
+// ParsedUA is a user agent decomposed into the components the generator
+// builds it from. Ids use the generator vocabulary: "win", "mac", "linux",
+// "android" for OS and "chrome", "firefox", "ie" for Navigator.
+type ParsedUA struct {
+	// Navigator is the browser id
+	Navigator string
+	// Version is the build version as in Navigator.BuildVersion,
+	// e.g. "86.0.4240.75", "50.0" or "MSIE 11.0"
+	Version string
+	// OS is the os id
+	OS string
+	// PlatformVersion is the OS_PLATFORM entry, e.g. "Windows NT 6.1"
+	PlatformVersion string
+	// UAPlatform is the platform part of the user agent as is
+	UAPlatform string
+	// CPU is the OS_CPU entry, e.g. "Win64; x64" or "x86_64"
+	CPU string
+	// DeviceType is "desktop", "smartphone" or "tablet"
+	DeviceType string
+	// DeviceModel is the android device id, e.g. "D5503 Build/14.6.A.1.236"
+	DeviceModel string
+	// Mobile reports whether the user agent has a "Mobile" token
+	Mobile bool
+}
+
+var (
+	reFirefoxUA = regexp.MustCompile(`^Mozilla/5\.0 \((.+); rv:([0-9.]+)\) Gecko/[0-9.]+ Firefox/([0-9.]+)$`)
+	reChromeUA  = regexp.MustCompile(`^Mozilla/5\.0 \(([^)]+)\) AppleWebKit/537\.36\s+\(KHTML, like Gecko\) Chrome/([0-9.]+) (Mobile )?Safari/537\.36$`)
+	reIEUA      = regexp.MustCompile(`^Mozilla/[45]\.0 \(compatible; (MSIE [0-9.]+); (.+); Trident/[0-9.]+\)$`)
+	reIE11UA    = regexp.MustCompile(`^Mozilla/5\.0 \((.+); Trident/[0-9.]+; rv:([0-9.]+)\) like Gecko$`)
+
+	reWinPlatform           = regexp.MustCompile(`^(Windows NT [0-9.]+)(?:; (.+))?$`)
+	reMacPlatform           = regexp.MustCompile(`^Macintosh; Intel Mac OS X ([0-9]+[._][0-9]+)(?:[._][0-9]+)?$`)
+	reLinuxPlatform         = regexp.MustCompile(`^(X11; (?:Ubuntu; )?Linux)(?: (.+))?$`)
+	reAndroidChromePlatform = regexp.MustCompile(`^Linux; (Android [0-9.]+); (.+)$`)
+	reAndroidFxPlatform     = regexp.MustCompile(`^(Android [0-9.]+); (Mobile|Tablet)$`)
+)
+
+// Parse decomposes a user agent into its components. It understands every
+// format the generator produces, see USERAGENTTEMPLATE, so parsing a
+// generated user agent recovers its os id, navigator id and build version.
+// Parse does not check the components are consistent with each other,
+// see Check for that.
+func Parse(ua string) (ParsedUA, error) {
+	var p ParsedUA
+	var platform string
+	if m := reFirefoxUA.FindStringSubmatch(ua); m != nil {
+		p.Navigator, p.Version, platform = "firefox", m[3], m[1]
+	} else if m := reChromeUA.FindStringSubmatch(ua); m != nil {
+		p.Navigator, p.Version, platform = "chrome", m[2], m[1]
+		p.Mobile = m[3] != ""
+	} else if m := reIEUA.FindStringSubmatch(ua); m != nil {
+		p.Navigator, p.Version, platform = "ie", m[1], m[2]
+	} else if m := reIE11UA.FindStringSubmatch(ua); m != nil {
+		p.Navigator, p.Version, platform = "ie", "MSIE "+m[2], m[1]
+	} else {
+		return ParsedUA{}, fmt.Errorf("%w: %q", ErrUnrecognizedUA, ua)
+	}
+	if !parsePlatform(&p, platform) {
+		return ParsedUA{}, fmt.Errorf("%w: unknown platform %q", ErrUnrecognizedUA, platform)
+	}
+	return p, nil
+}
+
+// Fill os, platform, cpu and device fields from the platform part
+// of a user agent. Returns false if the platform is unknown.
+func parsePlatform(p *ParsedUA, platform string) bool {
+	p.UAPlatform = platform
+	p.DeviceType = "desktop"
+	if m := reWinPlatform.FindStringSubmatch(platform); m != nil {
+		p.OS, p.PlatformVersion, p.CPU = "win", m[1], m[2]
+		return true
+	}
+	if m := reMacPlatform.FindStringSubmatch(platform); m != nil {
+		p.OS = "mac"
+		p.PlatformVersion = "Macintosh; Intel Mac OS X " + strings.Replace(m[1], "_", ".", 1)
+		return true
+	}
+	if m := reLinuxPlatform.FindStringSubmatch(platform); m != nil {
+		p.OS, p.PlatformVersion, p.CPU = "linux", m[1], m[2]
+		return true
+	}
+	if m := reAndroidChromePlatform.FindStringSubmatch(platform); m != nil {
+		p.OS, p.PlatformVersion, p.DeviceModel = "android", m[1], m[2]
+		if p.Mobile {
+			p.DeviceType = "smartphone"
+		} else {
+			p.DeviceType = "tablet"
+		}
+		return true
+	}
+	if m := reAndroidFxPlatform.FindStringSubmatch(platform); m != nil {
+		p.OS, p.PlatformVersion = "android", m[1]
+		if m[2] == "Mobile" {
+			p.Mobile = true
+			p.DeviceType = "smartphone"
+		} else {
+			p.DeviceType = "tablet"
+		}
+		return true
+	}
+	return false
+}
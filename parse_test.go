@@ -0,0 +1,91 @@
+package useragent
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseRoundTrip(t *testing.T) {
+	reg := defaultRegistry()
+	for _, os := range reg.OSes() {
+		for _, b := range reg.Browsers() {
+			for _, dev := range os.DeviceTypes() {
+				if !compatible(dev, os, b) {
+					continue
+				}
+				for _, platform := range supportedPlatforms(os, b) {
+					n := os.Variants(dev, platform, b.ID())
+					if n == 0 {
+						// no device ids, built with useragent_nodevices
+						continue
+					}
+					for _, i := range []int{0, n - 1} {
+						system, err := os.System(dev, platform, b.ID(), i)
+						if err != nil {
+							t.Fatal(err)
+						}
+						for _, build := range b.Builds(platform) {
+							app, err := b.App(os.ID(), build)
+							if err != nil {
+								t.Fatal(err)
+							}
+							nav, err := defaultGenerator.renderNavigator(dev, os.ID(), b, system, app)
+							if err != nil {
+								t.Fatal(err)
+							}
+							testParseNavigator(t, nav)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func testParseNavigator(t *testing.T, nav Navigator) {
+	t.Helper()
+	p, err := Parse(nav.UserAgent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.OS != nav.OSID || p.Navigator != nav.NavigatorID || p.Version != nav.BuildVersion {
+		t.Errorf("%s: got %s/%s/%s, want %s/%s/%s", nav.UserAgent,
+			p.OS, p.Navigator, p.Version, nav.OSID, nav.NavigatorID, nav.BuildVersion)
+	}
+	if p.DeviceType != nav.DeviceType || p.PlatformVersion != nav.PlatformVersion || p.DeviceModel != nav.DeviceID {
+		t.Errorf("%s: got %s %q %q, want %s %q %q", nav.UserAgent,
+			p.DeviceType, p.PlatformVersion, p.DeviceModel, nav.DeviceType, nav.PlatformVersion, nav.DeviceID)
+	}
+}
+
+func TestParse(t *testing.T) {
+	p, err := Parse("Mozilla/5.0 (X11; Ubuntu; Linux i686 on x86_64; rv:49.0) Gecko/20100101 Firefox/49.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ParsedUA{
+		Navigator:       "firefox",
+		Version:         "49.0",
+		OS:              "linux",
+		PlatformVersion: "X11; Ubuntu; Linux",
+		UAPlatform:      "X11; Ubuntu; Linux i686 on x86_64",
+		CPU:             "i686 on x86_64",
+		DeviceType:      "desktop",
+	}
+	if p != want {
+		t.Errorf("got %+v, want %+v", p, want)
+	}
+
+	p, err = Parse("Mozilla/5.0 (Linux; Android 6.0.1; D5503 Build/14.6.A.1.236) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/86.0.4240.75 Mobile Safari/537.36")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.DeviceType != "smartphone" || p.DeviceModel != "D5503 Build/14.6.A.1.236" || p.PlatformVersion != "Android 6.0.1" {
+		t.Errorf("got %+v", p)
+	}
+
+	_, err = Parse("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0")
+	if !errors.Is(err, ErrUnrecognizedUA) {
+		t.Errorf("edge: got %v, want ErrUnrecognizedUA", err)
+	}
+}
@@ -0,0 +1,255 @@
+package useragent
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type (
+	// System are the OS components of a user agent.
+	System struct {
+		// PlatformVersion is the OS name used in different places,
+		// e.g. "Windows NT 6.1"
+		PlatformVersion string
+		// Platform goes to navigator.platform
+		Platform string
+		// UAPlatform is used in building navigator.userAgent
+		UAPlatform string
+		// Oscpu goes to navigator.oscpu
+		Oscpu string
+		// CPU is the OS_CPU entry the system was built with
+		CPU string
+		// DeviceID is the device id of mobile systems
+		DeviceID string
+	}
+
+	// App are the browser components of a user agent.
+	App struct {
+		// Name goes to navigator.appName
+		Name           string
+		ProductSub     string
+		Vendor         string
+		BuildVersion   string
+		BuildID        string
+		GeckoTrail     string
+		TridentVersion string
+	}
+
+	// Build is a browser build version and its release date,
+	// Released is zero if the date is unknown.
+	Build struct {
+		Version  string
+		Released time.Time
+	}
+
+	// Component is implemented by OperatingSystem and Browser.
+	Component interface {
+		// ID is the identifier used in UserAgentConfig, e.g. "win" or "chrome"
+		ID() string
+		// DeviceTypes lists device types the component runs on,
+		// e.g. "desktop", "smartphone" or "tablet"
+		DeviceTypes() []string
+	}
+
+	// OperatingSystem builds the system components of user agents.
+	OperatingSystem interface {
+		Component
+		// Platforms lists the platform versions of the OS,
+		// e.g. "Windows NT 6.1"
+		Platforms() []string
+		// Variants returns the number of distinct systems of a platform
+		// for the device type and browser, e.g. cpus times device ids.
+		Variants(deviceType, platformVersion, navigatorID string) int
+		// System builds the i-th variant, 0 <= i < Variants.
+		System(deviceType, platformVersion, navigatorID string, i int) (System, error)
+	}
+
+	// Browser builds the app components and the User-Agent of a browser.
+	Browser interface {
+		Component
+		// OSes lists ids of the operating systems the browser runs on
+		OSes() []string
+		// Builds lists the builds that run on the platform version
+		Builds(platformVersion string) []Build
+		// App builds app components of a build on the OS
+		App(osID string, build Build) (App, error)
+		// Template returns the name and the text/template source of the
+		// User-Agent. The template is executed with the System and App
+		// components as fields of the same name.
+		Template(deviceType string, app App) (name, text string)
+		// AppVersion returns navigator.appVersion for the user agent
+		AppVersion(osID string, system System, userAgent string) string
+	}
+
+	// Registry is a set of operating systems and browsers the generator
+	// combines. The zero value is an empty registry.
+	Registry struct {
+		mu       sync.RWMutex
+		oses     []OperatingSystem
+		browsers []Browser
+		// Variants by config filter, see Registry.Variants
+		variantMu    sync.Mutex
+		variantCache map[string][]Variant
+		systemCache  map[string]systemSet
+		// Sorted release dates of the builds, see releasesBy
+		releases []time.Time
+		// Compiled browser templates by templateSource, dropped with
+		// the registry, e.g. when a data pack is reloaded
+		templates sync.Map
+	}
+)
+
+var (
+	defaultRegistryOnce sync.Once
+	defaultReg          *Registry
+)
+
+// Registry used by the package level functions
+func defaultRegistry() *Registry {
+	defaultRegistryOnce.Do(func() {
+		defaultReg = NewRegistry()
+	})
+	return defaultReg
+}
+
+// NewRegistry returns a registry with the built-in operating systems
+// "win", "mac", "linux", "android" and browsers "chrome", "firefox", "ie",
+// built from the package tables.
+func NewRegistry() *Registry {
+	return newRegistry(defaultTables())
+}
+
+func newRegistry(t *tables) *Registry {
+	r := new(Registry)
+	for _, c := range builtinComponents(t) {
+		r.Register(c)
+	}
+	return r
+}
+
+// Register makes an OperatingSystem or Browser available for generation
+// with the package level functions. It panics if c implements neither or
+// if a component with the same kind and id is already registered.
+func Register(c Component) {
+	defaultRegistry().Register(c)
+}
+
+// Register adds an OperatingSystem or Browser to the registry. It panics
+// if c implements neither or if a component with the same kind and id is
+// already registered.
+func (r *Registry) Register(c Component) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.variantMu.Lock()
+	r.variantCache = nil
+	r.systemCache = nil
+	r.releases = nil
+	r.variantMu.Unlock()
+	switch c := c.(type) {
+	case OperatingSystem:
+		for _, os := range r.oses {
+			if os.ID() == c.ID() {
+				panic("useragent: Register called twice for os " + c.ID())
+			}
+		}
+		r.oses = append(r.oses, c)
+	case Browser:
+		for _, b := range r.browsers {
+			if b.ID() == c.ID() {
+				panic("useragent: Register called twice for browser " + c.ID())
+			}
+		}
+		r.browsers = append(r.browsers, c)
+	default:
+		panic(fmt.Sprintf("useragent: Register of %T, neither OperatingSystem nor Browser", c))
+	}
+}
+
+// OS returns the registered operating system with the id.
+func (r *Registry) OS(id string) (OperatingSystem, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, os := range r.oses {
+		if os.ID() == id {
+			return os, true
+		}
+	}
+	return nil, false
+}
+
+// Browser returns the registered browser with the id.
+func (r *Registry) Browser(id string) (Browser, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, b := range r.browsers {
+		if b.ID() == id {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+// OSes returns the registered operating systems in registration order.
+func (r *Registry) OSes() []OperatingSystem {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]OperatingSystem(nil), r.oses...)
+}
+
+// Browsers returns the registered browsers in registration order.
+func (r *Registry) Browsers() []Browser {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Browser(nil), r.browsers...)
+}
+
+// Look up os and browser of a variant.
+func (r *Registry) components(osID, navigatorID string) (OperatingSystem, Browser, error) {
+	os, ok := r.OS(osID)
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown os: %s", osID)
+	}
+	b, ok := r.Browser(navigatorID)
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown browser: %s", navigatorID)
+	}
+	return os, b, nil
+}
+
+// Platforms of the os that can run at least one build of the browser.
+func supportedPlatforms(os OperatingSystem, b Browser) []string {
+	var platforms []string
+	for _, platform := range os.Platforms() {
+		if len(b.Builds(platform)) != 0 {
+			platforms = append(platforms, platform)
+		}
+	}
+	return platforms
+}
+
+// Builds of the browser on the platform released by cfg.AsOf, if not
+// zero, and within the versions cfg allows for the browser.
+func configBuilds(b Browser, platformVersion string, cfg *UserAgentConfig) []Build {
+	builds := b.Builds(platformVersion)
+	versions, limited := cfg.Versions[b.ID()]
+	if cfg.AsOf.IsZero() && !limited {
+		return builds
+	}
+	var allowed []Build
+	for _, build := range builds {
+		if !cfg.AsOf.IsZero() && (build.Released.IsZero() || build.Released.After(cfg.AsOf)) {
+			continue
+		}
+		if versions.Contains(Version(build.Version)) {
+			allowed = append(allowed, build)
+		}
+	}
+	return allowed
+}
+
+// Report whether the browser runs on the device type and os.
+func compatible(deviceType string, os OperatingSystem, b Browser) bool {
+	return contains(os.DeviceTypes(), deviceType) && contains(b.DeviceTypes(), deviceType) &&
+		contains(b.OSes(), os.ID()) && len(supportedPlatforms(os, b)) != 0
+}
@@ -0,0 +1,63 @@
+package useragent
+
+import (
+	"strings"
+	"testing"
+)
+
+type operaBrowser struct{}
+
+func (operaBrowser) ID() string            { return "opera" }
+func (operaBrowser) DeviceTypes() []string { return []string{"desktop"} }
+func (operaBrowser) OSes() []string        { return []string{"win", "linux"} }
+func (operaBrowser) Builds(platformVersion string) []Build {
+	return []Build{{Version: "72.0.3815.186"}}
+}
+func (operaBrowser) App(osID string, build Build) (App, error) {
+	return App{Name: "Netscape", BuildVersion: build.Version}, nil
+}
+func (operaBrowser) Template(deviceType string, app App) (string, string) {
+	return "opera", `Mozilla/5.0 ({{.System.UAPlatform}}) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/86.0.4240.75 Safari/537.36 OPR/{{.App.BuildVersion}}`
+}
+func (operaBrowser) AppVersion(osID string, system System, userAgent string) string {
+	return strings.TrimPrefix(userAgent, "Mozilla/")
+}
+
+func TestRegistryRegister(t *testing.T) {
+	reg := NewRegistry()
+	cfg := UserAgentConfig{OS: "linux", Navigator: "opera"}
+	if _, err := reg.Variants(cfg); err == nil {
+		t.Fatal("opera variants before Register")
+	}
+	reg.Register(operaBrowser{})
+	os, b, err := reg.components("linux", "opera")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !compatible("desktop", os, b) {
+		t.Fatal("opera on linux desktop is not compatible")
+	}
+	var nav Navigator
+	ok, err := reg.pickConfigIDs(&cfg, randIntn, nil, func(variant Variant) (bool, error) {
+		return reg.buildSystemComponents(&cfg, variant, randIntn, nil, nil, func(system System) (bool, error) {
+			return reg.buildAppComponents("linux", "opera", system.PlatformVersion, &cfg, randIntn, nil, nil, func(app App) (bool, error) {
+				var err error
+				nav, err = defaultGenerator.renderNavigator("desktop", "linux", b, system, app)
+				return true, err
+			})
+		})
+	})
+	if !ok || err != nil {
+		t.Fatal(ok, err)
+	}
+	if !strings.HasSuffix(nav.UserAgent, "OPR/72.0.3815.186") || !strings.Contains(nav.UserAgent, "X11; ") {
+		t.Errorf("user agent: %s", nav.UserAgent)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering opera twice did not panic")
+		}
+	}()
+	reg.Register(operaBrowser{})
+}
@@ -0,0 +1,106 @@
+// Code generated by internal/gendata from releases; DO NOT EDIT.
+
+package useragent
+
+import "time"
+
+var (
+	// Chrome builds, releases/chrome
+	CHROME_BUILD = []string{
+		"80.0.3987.99",
+		"80.0.3987.132",
+		"80.0.3987.149",
+		"81.0.4044.117",
+		"81.0.4044.138",
+		"83.0.4103.96",
+		"83.0.4103.101",
+		"83.0.4103.106",
+		"84.0.4147.89",
+		"84.0.4147.105",
+		"84.0.4147.111",
+		"84.0.4147.125",
+		"84.0.4147.135",
+		"85.0.4183.81",
+		"85.0.4183.83",
+		"85.0.4183.101",
+		"85.0.4183.102",
+		"85.0.4183.120",
+		"85.0.4183.121",
+		"85.0.4183.127",
+		"86.0.4240.75",
+		"86.0.4240.78",
+		"86.0.4240.80",
+		"86.0.4240.96",
+		"86.0.4240.99",
+		"86.0.4240.110",
+		"86.0.4240.111",
+		"86.0.4240.114",
+		"86.0.4240.183",
+		"86.0.4240.185",
+	}
+
+	// Release dates of the chrome majors listed in CHROME_BUILD
+	CHROME_RELEASE_DATE = map[int]time.Time{
+		80: time.Date(2020, 2, 4, 0, 0, 0, 0, time.UTC),
+		81: time.Date(2020, 4, 7, 0, 0, 0, 0, time.UTC),
+		83: time.Date(2020, 5, 19, 0, 0, 0, 0, time.UTC),
+		84: time.Date(2020, 7, 14, 0, 0, 0, 0, time.UTC),
+		85: time.Date(2020, 8, 25, 0, 0, 0, 0, time.UTC),
+		86: time.Date(2020, 10, 6, 0, 0, 0, 0, time.UTC),
+	}
+
+	// Firefox versions, releases/firefox
+	FIREFOX_VERSION = []FirefoxVersion{
+		{"45.0", time.Date(2016, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{"46.0", time.Date(2016, 4, 26, 0, 0, 0, 0, time.UTC)},
+		{"47.0", time.Date(2016, 6, 7, 0, 0, 0, 0, time.UTC)},
+		{"48.0", time.Date(2016, 8, 2, 0, 0, 0, 0, time.UTC)},
+		{"49.0", time.Date(2016, 9, 20, 0, 0, 0, 0, time.UTC)},
+		{"50.0", time.Date(2016, 11, 15, 0, 0, 0, 0, time.UTC)},
+		{"51.0", time.Date(2017, 1, 24, 0, 0, 0, 0, time.UTC)},
+	}
+
+	// Android platforms of OS_PLATFORM, releases/android
+	ANDROID_PLATFORM = []string{
+		// 2013-10-31
+		"Android 4.4",
+		// 2013-12-05
+		"Android 4.4.1",
+		// 2013-12-09
+		"Android 4.4.2",
+		// 2014-06-02
+		"Android 4.4.3",
+		// 2014-06-19
+		"Android 4.4.4",
+		// 2014-11-12
+		"Android 5.0",
+		// 2014-12-02
+		"Android 5.0.1",
+		// 2014-12-19
+		"Android 5.0.2",
+		// 2015-03-09
+		"Android 5.1",
+		// 2015-04-21
+		"Android 5.1.1",
+		// 2015-10-05
+		"Android 6.0",
+		// 2015-12-07
+		"Android 6.0.1",
+		// 2016-08-22
+		"Android 7.0",
+		// 2016-10-04
+		"Android 7.1",
+		// 2016-12-05
+		"Android 7.1.1",
+	}
+
+	// Minor builds of mac versions Chrome reports, [first, last+1),
+	// releases/macos
+	MACOSX_CHROME_BUILD_RANGE = map[string][]int{
+		"10.8":  {0, 8},
+		"10.9":  {0, 5},
+		"10.10": {0, 5},
+		"10.11": {0, 6},
+		"10.12": {0, 2},
+	}
+)
@@ -0,0 +1,171 @@
+package useragent
+
+import (
+	"fmt"
+	"iter"
+	"sort"
+)
+
+// space indexes every navigator a config can produce: navigator k is
+// found by its segment, a platform of a variant, then the system variant
+// and build within the segment. Denied user agents are in the space,
+// they are skipped when generated.
+type space struct {
+	segments []segment
+	total    int
+	deny     *denyList
+}
+
+type segment struct {
+	variant  Variant
+	platform string
+	os       OperatingSystem
+	browser  Browser
+	systems  systemSet
+	builds   []Build
+	// index of the first navigator of the segment
+	offset int
+}
+
+// Index space of the config
+func (r *Registry) space(cfg *UserAgentConfig) (*space, error) {
+	variants, err := r.variants(cfg)
+	if err != nil {
+		return nil, err
+	}
+	deny, err := cfg.denyList()
+	if err != nil {
+		return nil, err
+	}
+	s := &space{deny: deny}
+	for _, v := range variants {
+		os, b, err := r.components(v.OS, v.Navigator)
+		if err != nil {
+			return nil, err
+		}
+		for _, platform := range v.Platforms {
+			systems, err := r.systems(cfg, os, v.DeviceType, platform, v.Navigator)
+			if err != nil {
+				return nil, err
+			}
+			seg := segment{
+				variant:  v,
+				platform: platform,
+				os:       os,
+				browser:  b,
+				systems:  systems,
+				builds:   configBuilds(b, platform, cfg),
+				offset:   s.total,
+			}
+			if n := seg.systems.n * len(seg.builds); n != 0 {
+				s.segments = append(s.segments, seg)
+				s.total += n
+			}
+		}
+	}
+	return s, nil
+}
+
+// Navigator k of the space, 0 <= k < total
+func (s *space) navigator(g *Generator, k int) (Navigator, error) {
+	i := sort.Search(len(s.segments), func(i int) bool {
+		return s.segments[i].offset > k
+	}) - 1
+	seg := &s.segments[i]
+	k -= seg.offset
+	v := seg.variant
+	system, err := seg.os.System(v.DeviceType, seg.platform, v.Navigator, seg.systems.at(k/len(seg.builds)))
+	if err != nil {
+		return Navigator{}, err
+	}
+	app, err := seg.browser.App(v.OS, seg.builds[k%len(seg.builds)])
+	if err != nil {
+		return Navigator{}, err
+	}
+	return g.renderNavigator(v.DeviceType, v.OS, seg.browser, system, app)
+}
+
+// Upper bound of the distinct user agents of the space: the user agent
+// of a segment is built from the UAPlatform of the system and the build,
+// systems differing only by cpu, like Firefox on Android, share it
+func (s *space) userAgentBound() (int, error) {
+	bound := 0
+	for i := range s.segments {
+		seg := &s.segments[i]
+		v := seg.variant
+		ua_platforms := make(map[string]bool)
+		for j := 0; j < seg.systems.n; j++ {
+			system, err := seg.os.System(v.DeviceType, seg.platform, v.Navigator, seg.systems.at(j))
+			if err != nil {
+				return 0, err
+			}
+			ua_platforms[system.UAPlatform] = true
+		}
+		bound += len(ua_platforms) * len(seg.builds)
+	}
+	return bound, nil
+}
+
+// All returns an iterator over every navigator the package level
+// functions can generate for the config, see Generator.All.
+func All(cfg UserAgentConfig) (iter.Seq[Navigator], error) {
+	return defaultGenerator.All(cfg)
+}
+
+// All returns an iterator over every navigator the generator can produce
+// for the config: each platform version, system variant (cpu, mac minor
+// build, device id) and build of every variant. Navigators are distinct,
+// their user agents are not always, e.g. the cpu of Firefox on Android
+// is only in navigator.platform. Navigators with a user agent the config
+// denies are skipped. The iterator panics if a navigator cannot be
+// rendered, like GenerateNavigator.
+func (g *Generator) All(cfg UserAgentConfig) (iter.Seq[Navigator], error) {
+	s, err := g.reg().space(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	return func(yield func(Navigator) bool) {
+		for k := 0; k < s.total; k++ {
+			nav, err := s.navigator(g, k)
+			if err != nil {
+				panic(fmt.Sprintf("useragent: navigator %d of %d: %v", k, s.total, err))
+			}
+			if s.deny.denies(nav.UserAgent) {
+				continue
+			}
+			if !yield(nav) {
+				return
+			}
+		}
+	}, nil
+}
+
+// Count returns the number of navigators All yields for the config,
+// without generating them.
+func Count(cfg UserAgentConfig) (int, error) {
+	return defaultGenerator.Count(cfg)
+}
+
+// Count returns the number of navigators Generator.All yields
+// for the config, without generating them unless the config denies
+// user agents.
+func (g *Generator) Count(cfg UserAgentConfig) (int, error) {
+	s, err := g.reg().space(&cfg)
+	if err != nil {
+		return 0, err
+	}
+	if s.deny == nil {
+		return s.total, nil
+	}
+	n := 0
+	for k := 0; k < s.total; k++ {
+		nav, err := s.navigator(g, k)
+		if err != nil {
+			return 0, err
+		}
+		if !s.deny.denies(nav.UserAgent) {
+			n++
+		}
+	}
+	return n, nil
+}
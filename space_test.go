@@ -0,0 +1,55 @@
+package useragent
+
+import (
+	"testing"
+)
+
+func TestAll(t *testing.T) {
+	for _, cfg := range []UserAgentConfig{
+		{Navigator: "ie"},
+		{OS: "mac", Navigator: "chrome"},
+		{OS: "linux"},
+		{OS: "android", Navigator: "firefox", Platform: []string{"Android 7.0"}},
+	} {
+		n, err := Count(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		all, err := All(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen := make(map[Navigator]bool)
+		for nav := range all {
+			if seen[nav] {
+				t.Fatalf("%+v: duplicate navigator %+v", cfg, nav)
+			}
+			seen[nav] = true
+			if findings := Check(nav.UserAgent, nil, &nav); len(findings) != 0 {
+				t.Errorf("%s: %v", nav.UserAgent, findings)
+			}
+		}
+		if len(seen) != n {
+			t.Errorf("%+v: All yielded %d navigators, Count %d", cfg, len(seen), n)
+		}
+	}
+
+	// Every IE build on every windows platform and cpu
+	n, _ := Count(UserAgentConfig{Navigator: "ie"})
+	want := 0
+	for _, platform := range OS_PLATFORM["win"] {
+		r, _ := supportRange(SUPPORT_MATRIX["ie"], platform)
+		for _, v := range IE_VERSION {
+			if r.contains(v.NumericVersion) {
+				want += len(OS_CPU["win"])
+			}
+		}
+	}
+	if n != want {
+		t.Errorf("Count ie = %d, want %d", n, want)
+	}
+
+	if _, err := Count(UserAgentConfig{OS: "beos"}); err == nil {
+		t.Error("Count of invalid config: no error")
+	}
+}
@@ -0,0 +1,275 @@
+package useragent
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Variant is a valid combination of device type, os and browser,
+// with the platform versions of the os the browser runs on.
+type Variant struct {
+	DeviceType string `json:"device_type"`
+	OS         string `json:"os"`
+	Navigator  string `json:"navigator"`
+	// Platforms are the platform versions, e.g. "Windows NT 10.0"
+	Platforms []string `json:"platforms"`
+}
+
+// Variants returns the combinations the package level functions
+// generate for the config, see Registry.Variants.
+func Variants(uaconfig ...UserAgentConfig) ([]Variant, error) {
+	var cfg UserAgentConfig
+	if len(uaconfig) != 0 {
+		cfg = uaconfig[0]
+	}
+	return defaultRegistry().Variants(cfg)
+}
+
+// Variants returns the combinations of device type, os and browser the
+// config allows, in registration order of device types, oses and
+// browsers, with the platform versions in cfg.PlatformVersions having
+// builds released by cfg.AsOf and in cfg.Versions, and without what
+// cfg.Exclude leaves out except denied user agents, only known once
+// generated. An error is returned if the config has invalid values or if
+// nothing matches it. The combinations are computed once per config and
+// cached until the next Register.
+func (r *Registry) Variants(cfg UserAgentConfig) ([]Variant, error) {
+	variants, err := r.variants(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]Variant, len(variants))
+	for i, v := range variants {
+		v.Platforms = append([]string(nil), v.Platforms...)
+		res[i] = v
+	}
+	return res, nil
+}
+
+// Size cap of the variant cache, a full cache is emptied so configs
+// built from requests cannot grow it without bound
+const maxVariantCache = 256
+
+// Cached variants of the config, shared, callers must not modify them.
+func (r *Registry) variants(cfg *UserAgentConfig) ([]Variant, error) {
+	key := r.variantKey(cfg)
+	r.variantMu.Lock()
+	variants, ok := r.variantCache[key]
+	r.variantMu.Unlock()
+	if ok {
+		return variants, nil
+	}
+	variants, err := r.computeVariants(cfg)
+	if err != nil {
+		return nil, err
+	}
+	r.variantMu.Lock()
+	if r.variantCache == nil || len(r.variantCache) >= maxVariantCache {
+		r.variantCache = make(map[string][]Variant)
+	}
+	r.variantCache[key] = variants
+	r.variantMu.Unlock()
+	return variants, nil
+}
+
+// Cache key of the config filter. Lists are sets, sorted without
+// duplicates, and AsOf is the number of release dates by it, so configs
+// choosing the same variants share the key.
+func (r *Registry) variantKey(cfg *UserAgentConfig) string {
+	ex := &cfg.Exclude
+	return fmt.Sprintf("%s|%s|%s|%s|%d|%#v|%#v|%s|%s|%s|%s|%s|%s|%s", optionKey(cfg.OS), optionKey(cfg.Navigator), setKey(cfg.DeviceType),
+		setKey(cfg.Platform), r.releasesBy(cfg.AsOf), cfg.Versions, cfg.PlatformVersions, setKey(idStrings(ex.Navigators)), setKey(ex.Platforms),
+		setKey(ex.CPUs), setKey(ex.DeviceIDs), setKey(idStrings(cfg.OSes)), setKey(idStrings(cfg.Navigators)), setKey(idStrings(cfg.DeviceTypes)))
+}
+
+// Key of an untyped option, nil differs from an empty list as it changes
+// the default device types
+func optionKey(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return setKey([]string{v})
+	case []string:
+		return setKey(v)
+	case OSID:
+		return setKey([]string{string(v)})
+	case []OSID:
+		return setKey(idStrings(v))
+	case NavigatorID:
+		return setKey([]string{string(v)})
+	case []NavigatorID:
+		return setKey(idStrings(v))
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// Key of a list used as set
+func setKey(items []string) string {
+	items = slices.Clone(items)
+	slices.Sort(items)
+	return fmt.Sprintf("%q", slices.Compact(items))
+}
+
+// Number of release dates of the registered builds by t, -1 if t is
+// zero. Configs with the same number allow the same builds.
+func (r *Registry) releasesBy(t time.Time) int {
+	if t.IsZero() {
+		return -1
+	}
+	r.variantMu.Lock()
+	releases := r.releases
+	r.variantMu.Unlock()
+	if releases == nil {
+		// Not under variantMu, Register holds mu while taking it
+		releases = []time.Time{}
+		for _, b := range r.Browsers() {
+			for _, os := range r.OSes() {
+				for _, platform := range os.Platforms() {
+					for _, build := range b.Builds(platform) {
+						if !build.Released.IsZero() {
+							releases = append(releases, build.Released)
+						}
+					}
+				}
+			}
+		}
+		slices.SortFunc(releases, time.Time.Compare)
+		r.variantMu.Lock()
+		r.releases = releases
+		r.variantMu.Unlock()
+	}
+	n, _ := slices.BinarySearchFunc(releases, t, func(released, t time.Time) int {
+		if released.After(t) {
+			return 1
+		}
+		return -1
+	})
+	return n
+}
+
+func (r *Registry) computeVariants(cfg *UserAgentConfig) ([]Variant, error) {
+	oses, browsers := r.OSes(), r.Browsers()
+	var os_ids, navigator_ids, device_types, platforms []string
+	for _, os := range oses {
+		os_ids = append(os_ids, os.ID())
+		platforms = append(platforms, os.Platforms()...)
+		for _, dev := range os.DeviceTypes() {
+			if !contains(device_types, dev) {
+				device_types = append(device_types, dev)
+			}
+		}
+	}
+	for _, b := range browsers {
+		navigator_ids = append(navigator_ids, b.ID())
+	}
+
+	os_opt, err := typedOption("os", cfg.OS, cfg.OSes)
+	if err != nil {
+		return nil, err
+	}
+	nav_opt, err := typedOption("navigator", cfg.Navigator, cfg.Navigators)
+	if err != nil {
+		return nil, err
+	}
+	dev_opt := cfg.DeviceType
+	if len(cfg.DeviceTypes) != 0 {
+		if len(dev_opt) != 0 {
+			return nil, errors.New("Option device_type is set twice, untyped and typed")
+		}
+		dev_opt = idStrings(cfg.DeviceTypes)
+	}
+
+	default_dev_types := []string{"desktop"}
+	if os_opt != nil || nav_opt != nil || len(cfg.Platform) != 0 {
+		default_dev_types = device_types
+	}
+	dev_choices, err := getOptionChoices("device_type", dev_opt, default_dev_types, device_types)
+	if err != nil {
+		return nil, err
+	}
+	os_choices, err := getOptionChoices("os", os_opt, os_ids, os_ids)
+	if err != nil {
+		return nil, err
+	}
+	nav_choices, err := getOptionChoices("navigator", nav_opt, navigator_ids, navigator_ids)
+	if err != nil {
+		return nil, err
+	}
+	platform_choices, err := getOptionChoices("platform", cfg.Platform, platforms, platforms)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersionRanges("versions", cfg.Versions, navigator_ids); err != nil {
+		return nil, err
+	}
+	if err := checkVersionRanges("platform_versions", cfg.PlatformVersions, os_ids); err != nil {
+		return nil, err
+	}
+	for _, item := range idStrings(cfg.Exclude.Navigators) {
+		if !contains(navigator_ids, item) {
+			return nil, fmt.Errorf("Option exclude contains invalid navigator: %s", item)
+		}
+	}
+	for _, item := range cfg.Exclude.Platforms {
+		if !contains(platforms, item) {
+			return nil, fmt.Errorf("Option exclude contains invalid platform: %s", item)
+		}
+	}
+	if err := r.checkExclude(&cfg.Exclude); err != nil {
+		return nil, err
+	}
+
+	var variants []Variant
+	for _, dev := range device_types {
+		if !contains(dev_choices, dev) {
+			continue
+		}
+		for _, os := range oses {
+			if !contains(os_choices, os.ID()) {
+				continue
+			}
+			for _, b := range browsers {
+				if !contains(nav_choices, b.ID()) || contains(idStrings(cfg.Exclude.Navigators), b.ID()) || !compatible(dev, os, b) {
+					continue
+				}
+				var variant_platforms []string
+				for _, platform := range supportedPlatforms(os, b) {
+					if !contains(platform_choices, platform) || contains(cfg.Exclude.Platforms, platform) ||
+						!cfg.PlatformVersions[os.ID()].Contains(Version(platform)) || len(configBuilds(b, platform, cfg)) == 0 {
+						continue
+					}
+					systems, err := r.systems(cfg, os, dev, platform, b.ID())
+					if err != nil {
+						return nil, err
+					}
+					if systems.n != 0 {
+						variant_platforms = append(variant_platforms, platform)
+					}
+				}
+				if len(variant_platforms) != 0 {
+					variants = append(variants, Variant{dev, os.ID(), b.ID(), variant_platforms})
+				}
+			}
+		}
+	}
+	if len(variants) == 0 {
+		return nil, errors.New("Options device_type, os, navigator and platform conflicts with each other")
+	}
+	return variants, nil
+}
+
+// Check the ranges are keyed by known ids and have valid bounds
+func checkVersionRanges(opt_name string, ranges map[string]VersionRange, ids []string) error {
+	for id, r := range ranges {
+		if !contains(ids, id) {
+			return fmt.Errorf("Option %s contains invalid item: %s", opt_name, id)
+		}
+		if !r.validate() {
+			return fmt.Errorf("Option %s of %s has an invalid version range: %q-%q", opt_name, id, r.Min, r.Max)
+		}
+	}
+	return nil
+}
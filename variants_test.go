@@ -0,0 +1,115 @@
+package useragent
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestVariants(t *testing.T) {
+	variants, err := Variants()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range variants {
+		if v.DeviceType != "desktop" {
+			t.Errorf("default variant %v is not desktop", v)
+		}
+	}
+
+	variants, err = Variants(UserAgentConfig{Navigator: "ie"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(variants) != 1 || variants[0].DeviceType != "desktop" || variants[0].OS != "win" {
+		t.Errorf("ie variants: %v", variants)
+	}
+
+	variants, err = Variants(UserAgentConfig{OS: "android", Platform: []string{"Android 4.4"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(variants) == 0 {
+		t.Fatal("no android variants")
+	}
+	for _, v := range variants {
+		if v.OS != "android" || len(v.Platforms) != 1 || v.Platforms[0] != "Android 4.4" {
+			t.Errorf("android variant: %v", v)
+		}
+	}
+
+	// Returned variants are copies
+	variants[0].Platforms[0] = "Android 1.0"
+	again, _ := Variants(UserAgentConfig{OS: "android", Platform: []string{"Android 4.4"}})
+	if again[0].Platforms[0] != "Android 4.4" {
+		t.Error("modifying variants changed the cache")
+	}
+
+	for _, cfg := range []UserAgentConfig{
+		{OS: "win", DeviceType: []string{"smartphone"}},
+		{Navigator: "ie", OS: "linux"},
+		{OS: "beos"},
+		{OS: 42},
+		{OS: "win", Platform: []string{"Android 7.0"}},
+	} {
+		if _, err := Variants(cfg); err == nil {
+			t.Errorf("Variants(%+v): no error", cfg)
+		}
+	}
+}
+
+func TestGenerateNavigatorConfig(t *testing.T) {
+	cfg := UserAgentConfig{OS: []string{"mac", "linux"}, Navigator: "firefox"}
+	for i := 0; i < 50; i++ {
+		nav := GenerateNavigator(cfg)
+		if (nav.OSID != "mac" && nav.OSID != "linux") || nav.NavigatorID != "firefox" {
+			t.Fatalf("navigator %s/%s for %+v", nav.OSID, nav.NavigatorID, cfg)
+		}
+	}
+}
+
+func TestVariantCache(t *testing.T) {
+	r := NewRegistry()
+	// Lists are sets and AsOf counts by release dates
+	day := time.Date(2020, 10, 7, 0, 0, 0, 0, time.UTC)
+	for _, pair := range [][2]UserAgentConfig{
+		{{OS: []string{"win", "mac"}}, {OS: []string{"mac", "win", "mac"}}},
+		{{OS: "linux"}, {OS: []string{"linux"}}},
+		{{DeviceType: []string{"desktop", "tablet"}}, {DeviceType: []string{"tablet", "desktop"}}},
+		{{AsOf: day}, {AsOf: day.Add(time.Hour)}},
+		{{Exclude: Exclude{Platforms: []string{"Windows NT 5.1", "Windows NT 6.1"}}}, {Exclude: Exclude{Platforms: []string{"Windows NT 6.1", "Windows NT 5.1"}}}},
+	} {
+		if a, b := r.variantKey(&pair[0]), r.variantKey(&pair[1]); a != b {
+			t.Errorf("keys differ: %s, %s", a, b)
+		}
+		va, err := r.Variants(pair[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		vb, _ := r.Variants(pair[1])
+		if !reflect.DeepEqual(va, vb) {
+			t.Errorf("variants of %+v and %+v differ", pair[0], pair[1])
+		}
+	}
+	for _, pair := range [][2]UserAgentConfig{
+		{{}, {OS: []string{}}},
+		{{}, {AsOf: day}},
+		{{AsOf: day}, {AsOf: day.AddDate(-1, 0, 0)}},
+	} {
+		if r.variantKey(&pair[0]) == r.variantKey(&pair[1]) {
+			t.Errorf("same key for %+v and %+v", pair[0], pair[1])
+		}
+	}
+
+	// The cache is bounded
+	for i := 0; i < 2*maxVariantCache; i++ {
+		cfg := UserAgentConfig{Versions: map[string]VersionRange{"chrome": {Max: Version(fmt.Sprintf("86.0.%d", i))}}}
+		if _, err := r.Variants(cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(r.variantCache); n > maxVariantCache {
+		t.Errorf("%d cached variants", n)
+	}
+}
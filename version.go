@@ -0,0 +1,114 @@
+package useragent
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Version is a dotted version of a build or platform, e.g. "86.0.4240.75",
+// "MSIE 11.0" or "Windows NT 10.0". Versions compare numerically by their
+// dotted numbers, leading text ignored, so "10.0" is after "9.1".
+type Version string
+
+// Compare returns -1, 0 or +1 as v is before, equal to or after w.
+// Missing trailing numbers count as zero, "84" equals "84.0".
+func (v Version) Compare(w Version) int {
+	return compareParts(versionParts(string(v)), versionParts(string(w)))
+}
+
+// Major returns the first number of the version, e.g. 86 for "86.0.4240.75".
+func (v Version) Major() int {
+	return majorVersion(string(v))
+}
+
+// VersionRange is an inclusive range of versions, an empty bound does not
+// limit. A bound matches the versions it is a prefix of, so
+// VersionRange{Min: "47", Max: "50"} contains "50.0.2" but not "51.0".
+type VersionRange struct {
+	Min Version `json:"min,omitempty"`
+	Max Version `json:"max,omitempty"`
+}
+
+// Contains reports whether the version is in the range. A version without
+// numbers, e.g. "X11; Linux", is only in the unlimited range.
+func (r VersionRange) Contains(v Version) bool {
+	if r.Min == "" && r.Max == "" {
+		return true
+	}
+	parts := versionParts(string(v))
+	if len(parts) == 0 {
+		return false
+	}
+	if r.Min != "" && compareBound(parts, versionParts(string(r.Min))) < 0 {
+		return false
+	}
+	if r.Max != "" && compareBound(parts, versionParts(string(r.Max))) > 0 {
+		return false
+	}
+	return true
+}
+
+// Bounds must have a number, e.g. "84" or "Android 7"
+func (r VersionRange) validate() bool {
+	return (r.Min == "" || len(versionParts(string(r.Min))) != 0) &&
+		(r.Max == "" || len(versionParts(string(r.Max))) != 0)
+}
+
+// Compare the version to the bound on the numbers the bound has
+func compareBound(parts, bound []int) int {
+	if len(parts) > len(bound) {
+		parts = parts[:len(bound)]
+	}
+	return compareParts(parts, bound)
+}
+
+// Compare two dotted versions numerically, e.g. "86.0.4240.99" and
+// "86.0.4240.183". Leading non-numeric text like "MSIE " is ignored.
+func compareVersions(a, b string) int {
+	return compareParts(versionParts(a), versionParts(b))
+}
+
+func compareParts(pa, pb []int) int {
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func majorVersion(version string) int {
+	parts := versionParts(version)
+	if len(parts) == 0 {
+		return 0
+	}
+	return parts[0]
+}
+
+func versionParts(version string) []int {
+	version = strings.TrimLeftFunc(version, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	if version == "" {
+		return nil
+	}
+	var parts []int
+	for _, s := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
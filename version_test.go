@@ -0,0 +1,83 @@
+package useragent
+
+import "testing"
+
+func TestVersion(t *testing.T) {
+	for _, test := range []struct {
+		v, w Version
+		want int
+	}{
+		{"10.0", "9.1", 1},
+		{"84", "84.0", 0},
+		{"86.0.4240.99", "86.0.4240.183", -1},
+		{"MSIE 11.0", "MSIE 9.0", 1},
+		{"Windows NT 6.1", "Windows NT 10.0", -1},
+	} {
+		if got := test.v.Compare(test.w); got != test.want {
+			t.Errorf("%q.Compare(%q) = %d, want %d", test.v, test.w, got, test.want)
+		}
+	}
+	if m := Version("86.0.4240.75").Major(); m != 86 {
+		t.Errorf("major %d", m)
+	}
+
+	r := VersionRange{Min: "47", Max: "50"}
+	for v, want := range map[Version]bool{
+		"46.0.1": false,
+		"47.0":   true,
+		"50.0.2": true,
+		"51.0":   false,
+		"":       false,
+	} {
+		if r.Contains(v) != want {
+			t.Errorf("%+v contains %q: %v", r, v, !want)
+		}
+	}
+	if !(VersionRange{}).Contains("X11; Linux") {
+		t.Error("unlimited range does not contain a version without numbers")
+	}
+}
+
+func TestVersionConstraints(t *testing.T) {
+	cfg := UserAgentConfig{
+		OS:               "all",
+		Navigator:        []string{"chrome", "firefox"},
+		Versions:         map[string]VersionRange{"chrome": {Min: "84"}, "firefox": {Min: "47", Max: "50"}},
+		PlatformVersions: map[string]VersionRange{"android": {Min: "7"}, "win": {Min: "Windows NT 10"}},
+	}
+	all, err := All(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for nav := range all {
+		n++
+		major := Version(nav.BuildVersion).Major()
+		if nav.NavigatorID == "chrome" && major < 84 || nav.NavigatorID == "firefox" && (major < 47 || major > 50) {
+			t.Fatalf("%s %s out of range", nav.NavigatorID, nav.BuildVersion)
+		}
+		if nav.OSID == "android" && Version(nav.PlatformVersion).Major() < 7 ||
+			nav.OSID == "win" && nav.PlatformVersion != "Windows NT 10.0" {
+			t.Fatalf("platform %s out of range", nav.PlatformVersion)
+		}
+	}
+	if n == 0 {
+		t.Fatal("nothing generated")
+	}
+	for i := 0; i < 50; i++ {
+		nav := GenerateNavigator(cfg)
+		if nav.NavigatorID == "chrome" && Version(nav.BuildVersion).Major() < 84 {
+			t.Fatalf("generated chrome %s", nav.BuildVersion)
+		}
+	}
+
+	for _, cfg := range []UserAgentConfig{
+		{Versions: map[string]VersionRange{"opera": {Min: "1"}}},
+		{PlatformVersions: map[string]VersionRange{"win": {Min: "new"}}},
+		{Navigator: "chrome", Versions: map[string]VersionRange{"chrome": {Min: "90", Max: "80"}}},
+	} {
+		if _, err := Variants(cfg); err == nil {
+			t.Errorf("Variants(%+v): no error", cfg)
+		}
+	}
+}
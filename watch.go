@@ -0,0 +1,87 @@
+package useragent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ReloadDataPack loads the data pack file at path and, if it is valid and
+// a navigator of each platform of every variant of the generator config
+// is generated with it, makes the generator use it, see UseDataPack. On
+// error the generator keeps its data.
+func (g *Generator) ReloadDataPack(path string) error {
+	p, err := LoadDataPackFile(path)
+	if err != nil {
+		return err
+	}
+	r := p.NewRegistry()
+	if err := g.trial(r, p.Weights); err != nil {
+		return fmt.Errorf("data pack %s: %w", path, err)
+	}
+	g.setData(r, p.Weights)
+	return nil
+}
+
+// Generate the first navigator of each segment of the generator config
+// with the registry and weights, to check them before use
+func (g *Generator) trial(r *Registry, w *Weights) error {
+	c := g.seeded(0)
+	c.data.Store(&generatorData{r, w})
+	s, err := r.space(&c.config)
+	if err != nil {
+		return err
+	}
+	for _, seg := range s.segments {
+		if _, err := s.navigator(c, seg.offset); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WatchDataPack loads the data pack file at path, then polls it every
+// interval and reloads it when its size or modification time changes,
+// until ctx is done. The registry and weights are swapped atomically:
+// generation in progress finishes with the data it started with. A reload
+// that fails, e.g. a pack that does not validate, is reported to onError,
+// if not nil, and the last good data stays in use. The error of the
+// first load is returned, and nothing is watched then.
+func (g *Generator) WatchDataPack(ctx context.Context, path string, interval time.Duration, onError func(error)) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err := g.ReloadDataPack(path); err != nil {
+		return err
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			cur, err := os.Stat(path)
+			if err != nil {
+				// report a missing file once, reload when it is back
+				if info != nil && onError != nil {
+					onError(err)
+				}
+				info = nil
+				continue
+			}
+			if info != nil && cur.Size() == info.Size() && cur.ModTime().Equal(info.ModTime()) {
+				continue
+			}
+			info = cur
+			if err := g.ReloadDataPack(path); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,87 @@
+package useragent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchDataPack(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pack.json")
+	write := func(data string, mtime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		// distinct modification times whatever the file system resolution
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	builds := func(g *Generator) string {
+		b, _ := g.reg().Browser("firefox")
+		return b.Builds("X11; Linux")[0].Version
+	}
+	now := time.Now()
+
+	var g Generator
+	errs := make(chan error, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := g.WatchDataPack(ctx, filepath.Join(t.TempDir(), "none.json"), time.Millisecond, nil); err == nil {
+		t.Fatal("watching a missing file: no error")
+	}
+	write(`{"schema": 1, "replace": true, "builds": {"firefox": [{"version": "52.0"}]}, "weights": {"variants": {"desktop/linux/firefox": 1}}}`, now.Add(-time.Hour))
+	if err := g.WatchDataPack(ctx, path, time.Millisecond, func(err error) { errs <- err }); err != nil {
+		t.Fatal(err)
+	}
+	if v := builds(&g); v != "52.0" {
+		t.Fatalf("firefox %s after the first load, want 52.0", v)
+	}
+	if g.snapshot().weights == nil {
+		t.Fatal("weights of the pack not used")
+	}
+
+	write(`{"schema": 1, "replace": true, "builds": {"firefox": [{"version": "53.0"}]}}`, now.Add(-time.Minute))
+	deadline := time.Now().Add(5 * time.Second)
+	for builds(&g) != "53.0" {
+		if time.Now().After(deadline) {
+			t.Fatal("changed data pack not reloaded")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// The reloaded pack has no weights, the ones of the last are dropped
+	if g.snapshot().weights != nil {
+		t.Error("weights of the last data pack kept")
+	}
+
+	write(`{"schema": 1, "builds": {"firefox": [{"version": "v54"}]}}`, now)
+	select {
+	case err := <-errs:
+		t.Log(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("invalid data pack not reported")
+	}
+	if v := builds(&g); v != "53.0" {
+		t.Errorf("firefox %s after an invalid reload, want the last good 53.0", v)
+	}
+
+	// A valid pack failing to generate, the template only breaks on the
+	// chrome build of the pack, is not used either
+	write(`{"schema": 1, "builds": {"chrome": [{"version": "99.0.1"}]}, "replace": true,
+  "templates": {"chrome": "{{if eq .App.BuildVersion \"99.0.1\"}}Chrome{{else}}Mozilla/5.0 ({{.System.UAPlatform}}){{end}}"}}`, now.Add(time.Minute))
+	select {
+	case err := <-errs:
+		t.Log(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("data pack failing to generate not reported")
+	}
+	if v := builds(&g); v != "53.0" {
+		t.Errorf("firefox %s after a failing reload, want the last good 53.0", v)
+	}
+	if _, err := g.GenerateN(UserAgentConfig{}, 10); err != nil {
+		t.Errorf("generation after a failing reload: %v", err)
+	}
+}
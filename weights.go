@@ -0,0 +1,145 @@
+package useragent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Weights is a sampling distribution of user agents, e.g. learned from
+// the access log of a site with LogReport.Weights. Generation picks a
+// variant, then a platform version of it, then a build, each with
+// probability proportional to its weight among the ones the config allows.
+// Choices without weight are not picked unless none of the allowed ones
+// has a weight, then they are picked uniformly like without Weights.
+type Weights struct {
+	// Variants by "device_type/os/navigator", e.g. "desktop/win/chrome"
+	Variants map[string]float64 `json:"variants"`
+	// Platforms by platform version, e.g. "Windows NT 10.0"
+	Platforms map[string]float64 `json:"platforms"`
+	// Majors by navigator and browser major, e.g. "chrome 86"
+	Majors map[string]float64 `json:"majors"`
+}
+
+// Weights returns the shares of variants, platform versions and browser
+// majors of the recognized user agents of the report.
+func (report *LogReport) Weights() *Weights {
+	shares := func(counts map[string]int) map[string]float64 {
+		m := make(map[string]float64, len(counts))
+		for k, n := range counts {
+			m[k] = float64(n) / float64(report.Recognized)
+		}
+		return m
+	}
+	return &Weights{
+		Variants:  shares(report.ByVariant),
+		Platforms: shares(report.ByPlatform),
+		Majors:    shares(report.ByMajor),
+	}
+}
+
+// ReadWeights decodes weights in the JSON format of Weights.
+func ReadWeights(r io.Reader) (*Weights, error) {
+	var w Weights
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&w); err != nil {
+		return nil, fmt.Errorf("weights: %w", err)
+	}
+	if err := w.validate(); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (w *Weights) validate() error {
+	for _, m := range []map[string]float64{w.Variants, w.Platforms, w.Majors} {
+		for k, v := range m {
+			if v < 0 {
+				return fmt.Errorf("weights: negative weight %v of %q", v, k)
+			}
+		}
+	}
+	return nil
+}
+
+// SetWeights makes the generator sample with the weights,
+// uniformly again if w is nil. GenerateN with Unique or UniqueNavigator
+// ignores the weights.
+func (g *Generator) SetWeights(w *Weights) {
+	g.update(func(d *generatorData) { d.weights = w })
+}
+
+func (w *Weights) variant(v Variant) float64 {
+	return w.Variants[v.DeviceType+"/"+v.OS+"/"+v.Navigator]
+}
+
+func (w *Weights) platform(platformVersion string) float64 {
+	return w.Platforms[platformVersion]
+}
+
+func (w *Weights) build(navigatorID string, build Build) float64 {
+	return w.Majors[navigatorID+" "+strconv.Itoa(majorVersion(build.Version))]
+}
+
+// Random index in [0, n), weighted by weight if w is not nil
+// and any of the n weights is positive
+func (w *Weights) pick(intn func(int) (int, error), n int, weight func(i int) float64) (int, error) {
+	if w == nil {
+		return intn(n)
+	}
+	total := 0.0
+	for i := 0; i < n; i++ {
+		total += weight(i)
+	}
+	if total <= 0 {
+		return intn(n)
+	}
+	r, err := intn(1 << 30)
+	if err != nil {
+		return 0, err
+	}
+	x := float64(r) / (1 << 30) * total
+	for i := 0; i < n; i++ {
+		if x -= weight(i); x < 0 {
+			return i, nil
+		}
+	}
+	// rounding, the last choice with weight
+	for i := n - 1; ; i-- {
+		if weight(i) > 0 {
+			return i, nil
+		}
+	}
+}
+
+// Random index in [0, n) accepted by try, drawn like pick but without
+// replacement until try accepts one; false if try accepts none
+func (w *Weights) pickUntil(intn func(int) (int, error), n int, weight func(i int) float64, try func(i int) (bool, error)) (bool, error) {
+	// indexes left to draw, nil until a first one is rejected
+	var left []int
+	index := func(k int) int {
+		if left == nil {
+			return k
+		}
+		return left[k]
+	}
+	for m := n; m != 0; m-- {
+		k, err := w.pick(intn, m, func(k int) float64 { return weight(index(k)) })
+		if err != nil {
+			return false, err
+		}
+		if ok, err := try(index(k)); ok || err != nil {
+			return ok, err
+		}
+		if left == nil {
+			left = make([]int, n)
+			for i := range left {
+				left[i] = i
+			}
+		}
+		left[k] = left[m-1]
+	}
+	return false, nil
+}
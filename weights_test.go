@@ -0,0 +1,69 @@
+package useragent
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWeights(t *testing.T) {
+	uas := `Mozilla/5.0 (X11; Linux x86_64; rv:50.0) Gecko/20100101 Firefox/50.0
+Mozilla/5.0 (X11; Linux i686; rv:50.0) Gecko/20100101 Firefox/50.0
+Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/86.0.4240.75 Safari/537.36
+Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/85.0.4183.83 Safari/537.36
+curl/7.68.0
+`
+	report, err := AnalyzeUAList(strings.NewReader(uas))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := report.Weights()
+	if w.Variants["desktop/linux/firefox"] != 0.5 || w.Majors["chrome 86"] != 0.25 {
+		t.Errorf("weights: %+v", w)
+	}
+
+	// Round trip through the weight file
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(w); err != nil {
+		t.Fatal(err)
+	}
+	w, err = ReadWeights(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var g Generator
+	g.SetWeights(w)
+	counts := make(map[string]int)
+	for i := 0; i < 200; i++ {
+		nav := g.GenerateNavigator(UserAgentConfig{OS: "all"})
+		switch {
+		case nav.OSID == "linux" && nav.NavigatorID == "firefox" && nav.BuildVersion == "50.0":
+		case nav.OSID == "win" && nav.PlatformVersion == "Windows NT 10.0" && nav.NavigatorID == "chrome" &&
+			(majorVersion(nav.BuildVersion) == 85 || majorVersion(nav.BuildVersion) == 86):
+		default:
+			t.Fatalf("navigator outside of the weights: %s", nav.UserAgent)
+		}
+		counts[nav.NavigatorID]++
+	}
+	if counts["firefox"] < 50 || counts["chrome"] < 50 {
+		t.Errorf("navigator counts %v, want about 100 each", counts)
+	}
+
+	// Weights of choices the config does not allow are ignored
+	nav := g.GenerateNavigator(UserAgentConfig{Navigator: "ie"})
+	if nav.NavigatorID != "ie" {
+		t.Errorf("navigator %s, want ie", nav.NavigatorID)
+	}
+
+	for _, data := range []string{
+		`{"variants": {"desktop/win/chrome": -1}}`,
+		`{"browsers": {}}`,
+		`[`,
+	} {
+		if _, err := ReadWeights(strings.NewReader(data)); err == nil {
+			t.Errorf("ReadWeights(%s): no error", data)
+		}
+	}
+}